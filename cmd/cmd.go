@@ -12,7 +12,10 @@ import (
 
 	"github.com/ericm/yup/clean"
 	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/index"
+	"github.com/ericm/yup/rebuild"
 	"github.com/ericm/yup/sync"
+	"github.com/ericm/yup/tui"
 	"github.com/ericm/yup/update"
 	"github.com/ericm/yup/yupfile"
 )
@@ -24,7 +27,9 @@ type Arguments struct {
 	sync         bool
 	// Map of individual args
 	options map[string]bool
-	target  string
+	// Values of options such as --by <field>
+	values map[string]string
+	target string
 }
 
 type pair struct {
@@ -54,9 +59,21 @@ Custom operations:
     yup -c              Cleans cache and unused dependencies
     yup -C              Cleans AUR cache only
     yup -a [package(s)] Operates on the AUR exclusively
+    yup -Si, -Ai <package(s)> Shows package info, with every field for AUR packages
     yup -n [package(s)] Runs in non-ncurses mode
     yup -Y <Yupfile>    Install packages from a Yupfile
     yup -Qos            Orders installed packages by install size
+    yup -Qi, --browse   Browses installed packages to sort, filter, remove, reinstall or mark them
+    yup --by <field>    Searches by name, name-desc, maintainer, depends, makedepends,
+                        optdepends, checkdepends or provides (also with -Ss)
+    yup --config <file>, --root <dir>, --dbpath <dir>
+                        Reads another pacman.conf, root or database (also passed to pacman)
+    yup --check-rebuilds Finds AUR packages linked against missing libraries
+    yup --prebuild      Builds pending AUR updates without installing them
+    yup --sync-aur-index Downloads the AUR metadata for offline searches and lookups
+    yup --json          Prints searches, -Si, -Qos and pending upgrades as JSON
+    yup --selector <selector>
+                        Picks search results with ncurses, fzf, sk or custom
 `
 
 // Custom commands not to be passed to pacman
@@ -86,26 +103,87 @@ func init() {
 		commandShort[arg.a] = true
 		commandLong[arg.b] = true
 	}
+
+	// Custom commands without a short form
+	for _, arg := range []string{"check-rebuilds", "prebuild", "sync-aur-index", "browse"} {
+		commandLong[arg] = true
+	}
 }
 
-var arguments = &Arguments{sendToPacman: false, sync: false, options: make(map[string]bool), target: ""}
+// Long options that take a value
+var valueOptions = map[string]bool{"by": true, "config": true, "root": true, "dbpath": true, "selector": true}
+
+// Long options that change how other operations run
+var modifierOptions = map[string]bool{"json": true}
+
+// Long options pacman doesn't know, left out with their values when passing args to it.
+// --config, --root and --dbpath are pacman's own
+var yupOptions = map[string]bool{"by": true, "selector": true, "json": true}
+
+var arguments = &Arguments{sendToPacman: false, sync: false, options: make(map[string]bool), values: make(map[string]string), target: ""}
 
 // Execute initialises the arguments slice and parses args
 func Execute() error {
 	arguments.args = append(arguments.args, os.Args[1:]...)
 	arguments.genOptions()
+	if arguments.argExist("json") {
+		// Keep stdout for the JSON document
+		config.GetConfig().JSON = true
+		output.MessagesTo(os.Stderr)
+	}
+	if by, ok := arguments.values["by"]; ok {
+		if err := search.ValidBy(by); err != nil {
+			return err
+		}
+		// Searches take the field as part of the query
+		arguments.target = strings.TrimSpace(fmt.Sprintf("by:%s %s", by, arguments.target))
+	}
+	if selector, ok := arguments.values["selector"]; ok {
+		if err := config.ValidSelector(selector, config.GetConfig().UserFile.SelectorCommand); err != nil {
+			return err
+		}
+		config.GetConfig().UserFile.Selector = selector
+	}
 	arguments.isPacman()
 	if arguments.sendToPacman {
 		// send to pacman
 		sendToPacman(true)
-	} else {
-		return arguments.getActions()
+		return nil
 	}
-	return nil
+	if err := arguments.loadPacman(); err != nil {
+		return err
+	}
+	return arguments.getActions()
+}
+
+// loadPacman reads pacman.conf and initialises alpm.
+// --config, --root and --dbpath take precedence over the config file, like in pacman
+func (args *Arguments) loadPacman() error {
+	conf := config.GetConfig()
+	path, root, dbpath := conf.UserFile.PacmanConf, conf.UserFile.RootDir, conf.UserFile.DBPath
+	if value, ok := args.values["config"]; ok {
+		path = value
+	}
+	if value, ok := args.values["root"]; ok {
+		root = value
+	}
+	if value, ok := args.values["dbpath"]; ok {
+		dbpath = value
+	}
+	if len(path) == 0 {
+		path = "/etc/pacman.conf"
+	}
+
+	pacman, err := config.ReadPacmanConf(path, root, dbpath)
+	if err != nil {
+		return err
+	}
+	conf.Pacman = *pacman
+	return search.Init()
 }
 
 func sendToPacman(sudo bool) {
-	allArgs := append([]string{"pacman"}, arguments.args...)
+	allArgs := append([]string{"pacman"}, pacmanArgs(arguments.args)...)
 
 	var pacman *exec.Cmd
 	if sudo {
@@ -117,35 +195,90 @@ func sendToPacman(sudo bool) {
 	pacman.Run()
 }
 
+// pacmanArgs returns args without the options only yup knows
+func pacmanArgs(args []string) []string {
+	out := []string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		if len(arg) > 2 && arg[:2] == "--" {
+			name := strings.SplitN(arg[2:], "=", 2)
+			if yupOptions[name[0]] {
+				// Skip the value too when it's the next arg
+				if len(name) == 1 && valueOptions[name[0]] {
+					i++
+				}
+				continue
+			}
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
 // Arguments methods
 
 // Generates arguments.options
 func (args *Arguments) genOptions() {
+	targets := false
+	value := ""
 	for _, arg := range args.args {
-		if len(arg) > 1 && arg[:2] == "--" {
+		if len(value) > 0 {
+			args.values[value] = arg
+			value = ""
+			continue
+		}
+		if arg == "--" {
+			// Everything after -- is a target, so queries can exclude with -word
+			targets = true
+			continue
+		}
+		if targets || len(arg) == 0 {
+			args.addTarget(arg)
+		} else if len(arg) > 1 && arg[:2] == "--" {
 			// Long command
-			args.options[arg[2:]] = true
+			name := arg[2:]
+			if i := strings.Index(name, "="); i > 0 {
+				args.values[name[:i]] = name[i+1:]
+				name = name[:i]
+			} else if valueOptions[name] {
+				value = name
+			}
+			args.options[name] = true
 		} else if arg[:1] == "-" {
 			// Short command
 			for i := 1; i < len(arg); i++ {
 				args.options[arg[i:i+1]] = true
 			}
 		} else {
-			// Set targets
-			if len(args.target) > 0 {
-				args.target = fmt.Sprintf("%s %s", args.target, arg)
-			} else {
-				args.target = arg
-			}
+			args.addTarget(arg)
 		}
 	}
 }
 
+// Appends to arguments.target
+func (args *Arguments) addTarget(arg string) {
+	if len(args.target) > 0 {
+		args.target = fmt.Sprintf("%s %s", args.target, arg)
+	} else {
+		args.target = arg
+	}
+}
+
 // getActions routes the actions
 func (args *Arguments) getActions() error {
+	if args.sync && args.argExist("i", "info") && len(args.target) > 0 {
+		// -Ai
+		return args.info(true)
+	}
 	if args.sync {
-		if len(args.args) == 0 || (len(args.target) == 0 && args.argExist("a", "aur")) {
+		if len(args.target) == 0 {
 			// Update
+			if config.GetConfig().JSON {
+				return update.Plan(args.argExist("a", "aur"))
+			}
 			if args.argExist("a", "aur") {
 				return update.AurUpdate()
 			}
@@ -154,7 +287,7 @@ func (args *Arguments) getActions() error {
 		conFile := config.GetConfig()
 		conFile.Ncurses = args.argExist("n", "non-ncurses")
 		// Update if wanted
-		if conFile.UserFile.Update {
+		if conFile.UserFile.Update && !conFile.JSON {
 			// Refresh
 			output.Printf("Refreshing local repositories")
 			refresh := exec.Command("sudo", "pacman", "-Sy")
@@ -219,6 +352,11 @@ func (args *Arguments) getActions() error {
 		}
 		packs = append(packs, groups...)
 
+		if conFile.JSON {
+			search.Rank(args.target, packs)
+			return output.PrintJSON(output.ToJSON(packs, false))
+		}
+
 		search.SortPacks(args.target, packs)
 		return nil
 	}
@@ -239,6 +377,18 @@ func (args *Arguments) getActions() error {
 		return clean.Aur()
 	}
 
+	if args.argExist("check-rebuilds") {
+		return rebuild.Check()
+	}
+
+	if args.argExist("prebuild") {
+		return update.Prebuild()
+	}
+
+	if args.argExist("sync-aur-index") {
+		return index.Sync()
+	}
+
 	if args.argExist("c", "clean") {
 		return clean.Clean()
 	}
@@ -257,6 +407,14 @@ func (args *Arguments) getActions() error {
 		return nil
 	}
 
+	if args.argExist("browse") || (args.argExist("Q", "query") && args.argExist("i", "info") && len(args.target) == 0 && tui.Enabled()) {
+		// Installed package browser
+		if !tui.Enabled() {
+			return fmt.Errorf("--browse needs ncurses mode")
+		}
+		return search.Browse()
+	}
+
 	if args.argExist("Q", "query") {
 		// Check for custom flag; -Qos
 		// This sorts by Install size
@@ -264,6 +422,9 @@ func (args *Arguments) getActions() error {
 			output.Printf("Sorting your query by install size")
 			pacman, err := search.PacmanQi()
 			sort.Sort(bySize(pacman))
+			if err == nil && config.GetConfig().JSON {
+				return output.PrintJSON(output.ToJSON(pacman, false))
+			}
 
 			// Print sorted
 			for i, pack := range pacman {
@@ -290,7 +451,7 @@ func (args *Arguments) getActions() error {
 
 // isPacman checks if the commands are custom yup commands
 func (args *Arguments) isPacman() {
-	if args.argExist("a", "aur", "n", "non-ncurses") {
+	if args.argExist("a", "aur", "n", "non-ncurses") || (args.argExist("A") && args.argExist("i", "info")) {
 		// Custom args
 		args.sync = true
 		args.sendToPacman = false
@@ -298,7 +459,14 @@ func (args *Arguments) isPacman() {
 	}
 
 	for _, arg := range args.args {
+		if arg == "--" {
+			break
+		}
 		if len(arg) > 2 && arg[:2] == "--" {
+			if name := strings.Split(arg[2:], "=")[0]; valueOptions[name] || modifierOptions[name] {
+				// Modifies the search rather than being an operation
+				continue
+			}
 			args.sendToPacman = !customLong(arg[2:])
 			return
 		} else if len(arg) > 1 && arg[:1] == "-" {
@@ -321,6 +489,9 @@ func (s bySize) Less(i, j int) bool { return s[i].InstalledSizeInt < s[j].Instal
 
 // syncCheck checks -S argument options
 func (args *Arguments) syncCheck() error {
+	if args.argExist("i", "info") && len(args.target) > 0 && !args.argExist("h", "help", "l", "list", "g", "groups") {
+		return args.info(false)
+	}
 	if args.argExist("h", "help", "i", "info", "l", "list", "g", "groups") {
 		sendToPacman(false)
 		return nil
@@ -334,6 +505,9 @@ func (args *Arguments) syncCheck() error {
 	if args.argExist("y", "refresh") {
 		if args.argExist("u", "upgrade") {
 			// Upgrade
+			if config.GetConfig().JSON {
+				return update.Plan(false)
+			}
 			return update.Update()
 		}
 		// Refresh
@@ -355,6 +529,21 @@ func (args *Arguments) syncCheck() error {
 			return nil
 		}
 
+		if config.GetConfig().JSON {
+			packs, err := search.Pacman(args.target, false, false)
+			if err != nil {
+				return err
+			}
+			if len(args.target) > 0 {
+				aur, err := search.Aur(args.target, false, false)
+				if err != nil {
+					output.PrintErr("AUR query error: %s", err)
+				}
+				packs = append(packs, aur...)
+			}
+			return output.PrintJSON(output.ToJSON(packs, false))
+		}
+
 		// Only check Aur with a search query
 		if len(args.target) > 0 {
 			_, errA := search.Aur(args.target, true, false)
@@ -368,8 +557,80 @@ func (args *Arguments) syncCheck() error {
 		return err
 	}
 
+	if config.GetConfig().JSON {
+		return fmt.Errorf("--json only works with searches, -Si, -Qos and system upgrades")
+	}
+
 	// Default case
-	return sync.Sync(strings.Split(args.target, " "), true, false)
+	err := sync.Sync(strings.Split(args.target, " "), true, false)
+	if notFound, ok := err.(*sync.NotFoundError); ok {
+		return search.DidYouMean(notFound.Names)
+	}
+	return err
+}
+
+// info shows pacman -Si for repo packages and every AUR field for the rest
+func (args *Arguments) info(aurOnly bool) error {
+	names := strings.Fields(args.target)
+	if config.GetConfig().JSON {
+		return infoJSON(names, aurOnly)
+	}
+	if !aurOnly {
+		repo, rest, err := search.InRepos(names)
+		if err != nil {
+			return err
+		}
+		if len(repo) > 0 {
+			pacman := exec.Command("pacman", append([]string{"-Si"}, repo...)...)
+			output.SetStd(pacman)
+			if err := pacman.Run(); err != nil {
+				return err
+			}
+		}
+		names = rest
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	packs, err := search.AurInfo(names)
+	if err != nil {
+		return err
+	}
+	found := map[string]bool{}
+	for _, pack := range packs {
+		found[pack.Name] = true
+		output.PrintInfo(pack)
+	}
+	for _, name := range names {
+		if !found[name] {
+			output.PrintErr("Package '%s' was not found", name)
+		}
+	}
+	return nil
+}
+
+// infoJSON prints -Si as JSON, with repo packages first
+func infoJSON(names []string, aurOnly bool) error {
+	packs := []output.Package{}
+	if !aurOnly {
+		repo, rest, err := search.InRepos(names)
+		if err != nil {
+			return err
+		}
+		if packs, err = search.RepoInfo(repo); err != nil {
+			return err
+		}
+		names = rest
+	}
+	if len(names) > 0 {
+		aur, err := search.AurInfo(names)
+		if err != nil {
+			return err
+		}
+		packs = append(packs, aur...)
+	}
+	return output.PrintJSON(output.ToJSON(packs, true))
 }
 
 // Returns whether or not an arg exists
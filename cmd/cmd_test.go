@@ -1,6 +1,9 @@
 package cmd
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestExecute(t *testing.T) {
 	err := Execute()
@@ -10,3 +13,11 @@ func TestExecute(t *testing.T) {
 
 	t.Log(arguments.args)
 }
+
+func TestPacmanArgs(t *testing.T) {
+	args := []string{"-Q", "--selector", "fzf", "--by=name", "--json", "--root", "/mnt", "--dbpath=/mnt/db", "--", "--by"}
+	want := []string{"-Q", "--root", "/mnt", "--dbpath=/mnt/db", "--", "--by"}
+	if got := pacmanArgs(args); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
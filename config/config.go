@@ -12,17 +12,59 @@ import (
 
 // File struct
 type File struct {
-	SortMode       string `json:"sort_mode"`
-	Ncurses        bool   `json:"ncurses_mode"`
-	Update         bool   `json:"always_update_repos"`
-	PrintPkg       bool   `json:"print_pkgbuild"`
-	AskPkg         bool   `json:"ask_pkgbuild"`
-	AskRedo        bool   `json:"ask_redo"`
-	ConfigVersion  string `json:"version"`
-	SilentUpdate   bool   `json:"silent_update"`
-	PacmanLimit    int    `json:"pacman_limit"`
-	AurLimit       int    `json:"aur_limit"`
-	VimKeybindings bool   `json:"vim_keybindings"`
+	SortMode        string              `json:"sort_mode"`
+	Ncurses         bool                `json:"ncurses_mode"`
+	Update          bool                `json:"always_update_repos"`
+	PrintPkg        bool                `json:"print_pkgbuild"`
+	AskPkg          bool                `json:"ask_pkgbuild"`
+	AskRedo         bool                `json:"ask_redo"`
+	ConfigVersion   string              `json:"version"`
+	SilentUpdate    bool                `json:"silent_update"`
+	PacmanLimit     int                 `json:"pacman_limit"`
+	AurLimit        int                 `json:"aur_limit"`
+	VimKeybindings  bool                `json:"vim_keybindings"`
+	RebuildRules    []RebuildRule       `json:"rebuild_rules"`
+	CheckNews       bool                `json:"check_news"`
+	NewsURL         string              `json:"news_url"`
+	ExtraKeyrings   []string            `json:"extra_keyrings"`
+	PrebuildAllow   []string            `json:"prebuild_allow"`
+	SortWeights     SortWeights         `json:"sort_weights"`
+	PacmanConf      string              `json:"pacman_conf"`
+	RootDir         string              `json:"root_dir"`
+	DBPath          string              `json:"db_path"`
+	AurIndexURL     string              `json:"aur_index_url"`
+	AurIndexTTL     int                 `json:"aur_index_ttl"`
+	Keybindings     map[string][]string `json:"keybindings"`
+	Theme           output.Theme        `json:"theme"`
+	Selector        string              `json:"selector"`
+	SelectorCommand []string            `json:"selector_command"`
+}
+
+// SortWeights are the weights of each signal in the "weighted" sort mode
+type SortWeights struct {
+	Distance   float64 `json:"distance"`
+	Tokens     float64 `json:"tokens"`
+	Votes      float64 `json:"votes"`
+	Popularity float64 `json:"popularity"`
+	Installed  float64 `json:"installed"`
+	Repo       float64 `json:"repo"`
+}
+
+// RebuildRule describes where a language runtime installs versioned files.
+// Path and Version are regular expressions whose first group is the runtime version
+type RebuildRule struct {
+	Runtime string `json:"runtime"`
+	Path    string `json:"path"`
+	Version string `json:"version"`
+}
+
+// DefaultRebuildRules returns the rules for python, perl and ruby
+func DefaultRebuildRules() []RebuildRule {
+	return []RebuildRule{
+		{Runtime: "python", Path: `^usr/lib/python(3\.\d+)/`, Version: `^(\d+\.\d+)`},
+		{Runtime: "perl", Path: `^usr/lib/perl5/(\d+\.\d+)/`, Version: `^(\d+\.\d+)`},
+		{Runtime: "ruby", Path: `^usr/lib/ruby/(?:gems/|vendor_ruby/)?(\d+\.\d+)\.\d+/`, Version: `^(\d+\.\d+)`},
+	}
 }
 
 // Config struct
@@ -31,7 +73,9 @@ type Config struct {
 	ConfigDir  string
 	ConfigFile string
 	Ncurses    bool
+	JSON       bool
 	UserFile   File
+	Pacman     PacmanConf
 }
 
 // Files represents the config files / dirs
@@ -60,7 +104,9 @@ func ReadConfigFile(version string) error {
 
 	data, _ := ioutil.ReadAll(fileOpen)
 
-	var file File
+	// Options missing from older config files keep their defaults,
+	// as do actions missing from keybindings and repos missing from the theme
+	file := *defaultFile("")
 	errC := json.Unmarshal(data, &file)
 	if errC != nil {
 		// Problem parsing file
@@ -73,6 +119,13 @@ func ReadConfigFile(version string) error {
 		return nil
 	}
 
+	// Keys the user bound are taken from the default actions
+	user := struct {
+		Keybindings map[string][]string `json:"keybindings"`
+	}{}
+	json.Unmarshal(data, &user)
+	file.Keybindings = MergeKeybindings(DefaultKeybindings(), user.Keybindings)
+
 	if file.ConfigVersion != version {
 		// Ask user to remake config file
 		output.PrintIn("An update was detected. Remake config file? (y/n)")
@@ -102,14 +155,40 @@ func ReadConfigFile(version string) error {
 		}
 	}
 
+	if err := ValidateKeybindings(file.Keybindings); err != nil {
+		return err
+	}
+	if err := file.Theme.Validate(); err != nil {
+		return err
+	}
+	if err := ValidSelector(file.Selector, file.SelectorCommand); err != nil {
+		return err
+	}
+
 	// Set config
 	files.UserFile = file
+	output.SetTheme(file.Theme)
 	return nil
 }
 
 // InitConfig writes the initial JSON to the file
 func InitConfig(file *os.File, version string) error {
-	initFile := &File{
+	initFile := defaultFile(version)
+	write, err := json.MarshalIndent(initFile, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, errF := file.WriteAt(write, 0); errF != nil {
+		// Read file if error
+		ReadConfigFile(version)
+	}
+	files.UserFile = *initFile
+	return nil
+}
+
+// defaultFile returns the default config
+func defaultFile(version string) *File {
+	return &File{
 		SortMode:       "closest",
 		Ncurses:        true,
 		Update:         false,
@@ -121,15 +200,24 @@ func InitConfig(file *os.File, version string) error {
 		PacmanLimit:    200,
 		AurLimit:       200,
 		VimKeybindings: false,
+		RebuildRules:   DefaultRebuildRules(),
+		CheckNews:      true,
+		NewsURL:        "https://archlinux.org/feeds/news/",
+		ExtraKeyrings:  []string{},
+		PrebuildAllow:  []string{},
+		SortWeights: SortWeights{
+			Distance:   4,
+			Tokens:     2,
+			Votes:      1,
+			Popularity: 1,
+			Installed:  0.5,
+			Repo:       1,
+		},
+		PacmanConf:      "/etc/pacman.conf",
+		AurIndexTTL:     24,
+		Keybindings:     DefaultKeybindings(),
+		Theme:           output.DefaultTheme(),
+		Selector:        "ncurses",
+		SelectorCommand: []string{},
 	}
-	write, err := json.MarshalIndent(initFile, "", "  ")
-	if err != nil {
-		return err
-	}
-	if _, errF := file.WriteAt(write, 0); errF != nil {
-		// Read file if error
-		ReadConfigFile(version)
-	}
-	files.UserFile = *initFile
-	return nil
 }
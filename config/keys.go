@@ -0,0 +1,148 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Actions of the ncurses lists that keys can be bound to
+var Actions = []string{
+	"up", "down", "pageup", "pagedown", "top", "bottom",
+	"toggle", "install", "remove", "upstream", "details",
+	"filter", "next", "previous",
+	"preview", "pkgbuild", "previewup", "previewdown",
+	"sort", "asdeps", "asexplicit", "files",
+	"redraw", "quit",
+}
+
+// Codes of the named keys, as ncurses reports them
+var keyNames = map[string]int{
+	"up":        259,
+	"down":      258,
+	"left":      260,
+	"right":     261,
+	"home":      262,
+	"end":       360,
+	"pageup":    339,
+	"pagedown":  338,
+	"backspace": 263,
+	"enter":     '\n',
+	"space":     ' ',
+	"tab":       '\t',
+	"esc":       27,
+}
+
+// Keys typed to pick packages by number
+const reservedKeys = "0123456789-^"
+
+// DefaultKeybindings returns the keys of each action
+func DefaultKeybindings() map[string][]string {
+	return map[string][]string{
+		"up":          {"w", "up"},
+		"down":        {"s", "down"},
+		"pageup":      {"pageup"},
+		"pagedown":    {"pagedown"},
+		"top":         {"home"},
+		"bottom":      {"end"},
+		"toggle":      {"enter", "space"},
+		"install":     {"i", "z"},
+		"remove":      {"r"},
+		"upstream":    {"u"},
+		"details":     {"d"},
+		"filter":      {"/"},
+		"next":        {"n"},
+		"previous":    {"N"},
+		"preview":     {"p"},
+		"pkgbuild":    {"P"},
+		"previewup":   {"["},
+		"previewdown": {"]"},
+		"sort":        {"o"},
+		"asdeps":      {"m"},
+		"asexplicit":  {"e"},
+		"files":       {"l"},
+		"redraw":      {"f"},
+		"quit":        {"q", "esc"},
+	}
+}
+
+// MergeKeybindings binds the actions in user over the defaults.
+// A key the user bound is taken from any default action it was bound to,
+// so new defaults don't clash with existing configs
+func MergeKeybindings(defaults, user map[string][]string) map[string][]string {
+	taken := map[int]bool{}
+	for _, keys := range user {
+		for _, key := range keys {
+			if code, err := ParseKey(key); err == nil {
+				taken[code] = true
+			}
+		}
+	}
+
+	merged := map[string][]string{}
+	for action, keys := range defaults {
+		if _, ok := user[action]; ok {
+			continue
+		}
+		kept := []string{}
+		for _, key := range keys {
+			if code, err := ParseKey(key); err != nil || !taken[code] {
+				kept = append(kept, key)
+			}
+		}
+		merged[action] = kept
+	}
+	for action, keys := range user {
+		merged[action] = keys
+	}
+	return merged
+}
+
+// ParseKey returns the code of a single character or a named key, such as pageup or esc
+func ParseKey(key string) (int, error) {
+	if code, ok := keyNames[strings.ToLower(key)]; ok {
+		return code, nil
+	}
+	if r, size := utf8.DecodeRuneInString(key); size == len(key) && r >= 32 && r < 127 {
+		return int(r), nil
+	}
+	return 0, fmt.Errorf("unknown key %q", key)
+}
+
+// ValidateKeybindings checks that every action and key is known,
+// and that no key is bound twice
+func ValidateKeybindings(bindings map[string][]string) error {
+	known := map[string]bool{}
+	for _, action := range Actions {
+		known[action] = true
+	}
+
+	// Sorted so errors are the same every time
+	actions := []string{}
+	for action := range bindings {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	bound := map[int]string{}
+	for _, action := range actions {
+		if !known[action] {
+			return fmt.Errorf("keybindings: unknown action %q, expected one of %s", action, strings.Join(Actions, ", "))
+		}
+		for _, key := range bindings[action] {
+			code, err := ParseKey(key)
+			if err != nil {
+				return fmt.Errorf("keybindings: %s: %s", action, err)
+			}
+			if strings.ContainsRune(reservedKeys, rune(code)) {
+				return fmt.Errorf("keybindings: %s: %q is used to type package numbers", action, key)
+			}
+			if other, ok := bound[code]; ok {
+				return fmt.Errorf("keybindings: %q is bound to both %s and %s", key, other, action)
+			}
+			bound[code] = action
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateKeybindings(t *testing.T) {
+	if err := ValidateKeybindings(DefaultKeybindings()); err != nil {
+		t.Fatalf("defaults: %s", err)
+	}
+
+	tests := []struct {
+		bindings map[string][]string
+		valid    bool
+	}{
+		{map[string][]string{"up": {"k", "up"}, "down": {"j", "down"}}, true},
+		{map[string][]string{"install": {"Enter"}}, true},
+		{map[string][]string{"jump": {"g"}}, false},
+		{map[string][]string{"up": {"ctrl-x"}}, false},
+		{map[string][]string{"up": {"k"}, "down": {"k"}}, false},
+		{map[string][]string{"toggle": {"5"}}, false},
+	}
+	for _, test := range tests {
+		if err := ValidateKeybindings(test.bindings); (err == nil) != test.valid {
+			t.Errorf("%v: got %v, want valid %v", test.bindings, err, test.valid)
+		}
+	}
+}
+
+func TestMergeKeybindings(t *testing.T) {
+	// Written before o and l had default actions
+	user := map[string][]string{"upstream": {"o"}, "details": {"L", "l"}}
+	merged := MergeKeybindings(DefaultKeybindings(), user)
+	if err := ValidateKeybindings(merged); err != nil {
+		t.Fatalf("merged: %s", err)
+	}
+	if !reflect.DeepEqual(merged["upstream"], []string{"o"}) || len(merged["sort"]) != 0 || len(merged["files"]) != 0 {
+		t.Errorf("user keys not taken: %v", merged)
+	}
+	if !reflect.DeepEqual(merged["quit"], []string{"q", "esc"}) {
+		t.Errorf("defaults not kept: %v", merged["quit"])
+	}
+
+	// Bindings the user clashes with themselves are still caught
+	merged = MergeKeybindings(DefaultKeybindings(), map[string][]string{"up": {"k"}, "down": {"k"}})
+	if err := ValidateKeybindings(merged); err == nil {
+		t.Error("clashing user bindings are valid")
+	}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// PacmanConf is the subset of pacman.conf used by yup
+type PacmanConf struct {
+	RootDir      string
+	DBPath       string
+	LogFile      string
+	CacheDir     []string
+	Architecture string
+	SigLevel     []string
+	IgnorePkg    []string
+	Color        bool
+	Repos        []Repo
+}
+
+// Repo is a repository section of pacman.conf
+type Repo struct {
+	Name     string
+	Servers  []string
+	SigLevel []string
+	Usage    []string
+}
+
+// ReadPacmanConf parses a pacman.conf and the files it includes.
+// A non-empty root or dbpath overrides the one in the file, like pacman's --root and --dbpath
+func ReadPacmanConf(path, root, dbpath string) (*PacmanConf, error) {
+	conf := &PacmanConf{}
+	section := ""
+	if err := conf.parse(path, &section); err != nil {
+		return nil, err
+	}
+
+	if len(root) > 0 {
+		conf.RootDir = root
+		// The database moves with the root unless given as well
+		conf.DBPath = ""
+	}
+	if len(dbpath) > 0 {
+		conf.DBPath = dbpath
+	}
+	if len(conf.RootDir) == 0 {
+		conf.RootDir = "/"
+	}
+	if len(conf.DBPath) == 0 {
+		conf.DBPath = filepath.Join(conf.RootDir, "var/lib/pacman") + "/"
+	}
+	if len(conf.LogFile) == 0 {
+		conf.LogFile = filepath.Join(conf.RootDir, "var/log/pacman.log")
+	}
+	if len(conf.CacheDir) == 0 {
+		conf.CacheDir = []string{filepath.Join(conf.RootDir, "var/cache/pacman/pkg") + "/"}
+	}
+
+	arch := conf.arch()
+	for i := range conf.Repos {
+		repo := &conf.Repos[i]
+		for j, server := range repo.Servers {
+			server = strings.ReplaceAll(server, "$repo", repo.Name)
+			repo.Servers[j] = strings.ReplaceAll(server, "$arch", arch)
+		}
+	}
+	return conf, nil
+}
+
+// parse reads one file into conf. section carries over into included files
+func (conf *PacmanConf) parse(path string, section *string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			*section = line[1 : len(line)-1]
+			if *section != "options" {
+				conf.Repos = append(conf.Repos, Repo{Name: *section})
+			}
+			continue
+		}
+
+		key, value := line, ""
+		if i := strings.Index(line, "="); i >= 0 {
+			key, value = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+		if len(*section) == 0 {
+			return fmt.Errorf("%s:%d: %s is not in a section", path, n, key)
+		}
+
+		if key == "Include" {
+			if !filepath.IsAbs(value) {
+				value = filepath.Join(filepath.Dir(path), value)
+			}
+			matches, err := filepath.Glob(value)
+			if err != nil {
+				return err
+			}
+			for _, match := range matches {
+				if err := conf.parse(match, section); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if *section == "options" {
+			conf.setOption(key, value)
+		} else {
+			conf.Repos[len(conf.Repos)-1].setOption(key, value)
+		}
+	}
+	return scanner.Err()
+}
+
+func (conf *PacmanConf) setOption(key, value string) {
+	switch key {
+	case "RootDir":
+		conf.RootDir = value
+	case "DBPath":
+		conf.DBPath = value
+	case "LogFile":
+		conf.LogFile = value
+	case "CacheDir":
+		conf.CacheDir = append(conf.CacheDir, value)
+	case "Architecture":
+		conf.Architecture = value
+	case "SigLevel":
+		conf.SigLevel = append(conf.SigLevel, strings.Fields(value)...)
+	case "IgnorePkg":
+		conf.IgnorePkg = append(conf.IgnorePkg, strings.Fields(value)...)
+	case "Color":
+		conf.Color = true
+	}
+}
+
+func (repo *Repo) setOption(key, value string) {
+	switch key {
+	case "Server":
+		repo.Servers = append(repo.Servers, value)
+	case "SigLevel":
+		repo.SigLevel = append(repo.SigLevel, strings.Fields(value)...)
+	case "Usage":
+		repo.Usage = append(repo.Usage, strings.Fields(value)...)
+	}
+}
+
+// arch returns the architecture $arch expands to in servers
+func (conf *PacmanConf) arch() string {
+	arch := strings.Fields(conf.Architecture)
+	if len(arch) > 0 && arch[0] != "auto" {
+		return arch[0]
+	}
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "386":
+		return "i686"
+	case "arm64":
+		return "aarch64"
+	}
+	return runtime.GOARCH
+}
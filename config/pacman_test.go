@@ -0,0 +1,58 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadPacmanConf(t *testing.T) {
+	path := filepath.Join("testdata", "pacman.conf")
+	conf, err := ReadPacmanConf(path, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conf.RootDir != "/tmp/fixture" || conf.DBPath != "/tmp/fixture/var/lib/pacman/" || !conf.Color {
+		t.Errorf("options not parsed: %+v", conf)
+	}
+	if !reflect.DeepEqual(conf.CacheDir, []string{"/tmp/fixture/cache/"}) {
+		t.Errorf("got cache dirs %v", conf.CacheDir)
+	}
+	if !reflect.DeepEqual(conf.IgnorePkg, []string{"linux", "linux-headers", "nvidia"}) {
+		t.Errorf("got ignored %v", conf.IgnorePkg)
+	}
+	if !reflect.DeepEqual(conf.SigLevel, []string{"Required", "DatabaseOptional"}) {
+		t.Errorf("got siglevel %v", conf.SigLevel)
+	}
+
+	want := []Repo{
+		{Name: "core", Servers: []string{"https://geo.mirror.pkgbuild.com/core/os/x86_64"}},
+		{
+			Name:     "extra",
+			Servers:  []string{"https://geo.mirror.pkgbuild.com/extra/os/x86_64"},
+			SigLevel: []string{"PackageOptional"},
+			Usage:    []string{"Sync", "Search"},
+		},
+		{Name: "custom", Servers: []string{"file:///home/custompkgs"}},
+	}
+	if !reflect.DeepEqual(conf.Repos, want) {
+		t.Errorf("got repos %+v", conf.Repos)
+	}
+
+	// Overrides, with the database following the root
+	conf, err = ReadPacmanConf(path, "/mnt", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.RootDir != "/mnt" || conf.DBPath != "/mnt/var/lib/pacman/" {
+		t.Errorf("root override: got %s and %s", conf.RootDir, conf.DBPath)
+	}
+	conf, err = ReadPacmanConf(path, "/mnt", "/db/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.DBPath != "/db/" {
+		t.Errorf("dbpath override: got %s", conf.DBPath)
+	}
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Selectors pick search results to install. ncurses is the built-in list, or the numbered prompt with -n.
+// fzf and sk are presets of a fuzzy finder command, and custom runs selector_command
+var Selectors = []string{"ncurses", "fzf", "sk", "custom"}
+
+// Commands of the preset selectors. Only the number, repo and name columns are hidden,
+// and {preview} is replaced by the command printing a package's info
+var selectorPresets = map[string][]string{
+	"fzf": {"fzf", "--multi", "--ansi", "--tiebreak=index", "--delimiter=\t", "--with-nth=4..", "--prompt=Install> ", "--preview={preview}"},
+	"sk":  {"sk", "--multi", "--ansi", "--tiebreak=index", "--delimiter=\t", "--with-nth=4..", "--prompt=Install> ", "--preview={preview}"},
+}
+
+// ValidSelector checks if selector can be set in the config or passed to --selector.
+// custom needs the command it runs
+func ValidSelector(selector string, command []string) error {
+	for _, s := range Selectors {
+		if s != selector {
+			continue
+		}
+		if selector == "custom" && len(command) == 0 {
+			return fmt.Errorf("The custom selector needs a selector_command")
+		}
+		return nil
+	}
+	return fmt.Errorf("Unknown selector %s, use one of: %s", selector, strings.Join(Selectors, ", "))
+}
+
+// SelectorCommand returns the command and arguments picking search results, or nil for the built-in list
+func SelectorCommand(selector string, command []string) []string {
+	if selector == "custom" {
+		return command
+	}
+	return selectorPresets[selector]
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidSelector(t *testing.T) {
+	command := []string{"peco", "--prompt=Install>"}
+	for _, selector := range Selectors {
+		if err := ValidSelector(selector, command); err != nil {
+			t.Errorf("%s: %s", selector, err)
+		}
+	}
+	if err := ValidSelector("dmenu", command); err == nil {
+		t.Error("dmenu: expected an error")
+	}
+	if err := ValidSelector("custom", nil); err == nil {
+		t.Error("custom without a command: expected an error")
+	}
+}
+
+func TestSelectorCommand(t *testing.T) {
+	command := []string{"peco", "--prompt=Install>"}
+	if got := SelectorCommand("fzf", command); len(got) == 0 || got[0] != "fzf" {
+		t.Errorf("fzf: got %q", got)
+	}
+	if got := SelectorCommand("custom", command); !reflect.DeepEqual(got, command) {
+		t.Errorf("custom: got %q", got)
+	}
+	if got := SelectorCommand("ncurses", command); got != nil {
+		t.Errorf("ncurses: got %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const stateFileName = "state.json"
+
+// State is data yup keeps between runs
+type State struct {
+	NewsSeen time.Time           `json:"news_seen"`
+	Reviewed map[string]string   `json:"reviewed"`
+	Prebuilt map[string]Prebuilt `json:"prebuilt"`
+}
+
+// Prebuilt represents packages built by yup --prebuild that are waiting to be installed
+type Prebuilt struct {
+	Commit  string   `json:"commit"`
+	Version string   `json:"version"`
+	Files   []string `json:"files"`
+}
+
+// ReadState reads the state file from the cache dir
+func ReadState() (*State, error) {
+	state := &State{}
+	data, err := ioutil.ReadFile(filepath.Join(files.CacheDir, stateFileName))
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		// Start over rather than failing on a corrupt state
+		return &State{}, nil
+	}
+	return state, nil
+}
+
+// WriteState writes the state file to the cache dir
+func WriteState(state *State) error {
+	write, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(files.CacheDir, stateFileName), write, 0644)
+}
+
+// UpdateState reads the state file, changes it with update and writes it back.
+// Reading it right before writing keeps what other yup processes wrote meanwhile
+func UpdateState(update func(*State)) error {
+	state, err := ReadState()
+	if err != nil {
+		return err
+	}
+	update(state)
+	return WriteState(state)
+}
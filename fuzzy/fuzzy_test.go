@@ -0,0 +1,32 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"yup", "", 3},
+		{"kitten", "sitting", 3},
+		{"visual-studio-code-bin", "visual-studio-cod-bin", 1},
+		{"日本語", "日本", 1},
+	}
+	for _, c := range cases {
+		if got := Distance(c.a, c.b); got != c.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	cands := []string{"visual-studio-code-bin", "visual-studio-code-insiders-bin", "code", "vscodium-bin"}
+	got := Closest("visual-studio-cod-bin", cands, 2, 3)
+	if want := []string{"visual-studio-code-bin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,288 @@
+package index
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/output"
+)
+
+// DefaultURL is the AUR's metadata dump
+const DefaultURL = "https://aur.archlinux.org/packages-meta-ext-v1.json.gz"
+
+const fileName = "aur-index.json.gz"
+
+// Pkg is an AUR package, as found in the metadata dump and RPC responses.
+// Empty fields are left out of the index
+type Pkg struct {
+	Name           string   `json:"Name"`
+	PackageBase    string   `json:"PackageBase"`
+	Version        string   `json:"Version"`
+	Description    string   `json:"Description,omitempty"`
+	URL            string   `json:"URL,omitempty"`
+	NumVotes       int      `json:"NumVotes,omitempty"`
+	Popularity     float64  `json:"Popularity,omitempty"`
+	OutOfDate      int      `json:"OutOfDate,omitempty"`
+	Maintainer     string   `json:"Maintainer,omitempty"`
+	CoMaintainers  []string `json:"CoMaintainers,omitempty"`
+	FirstSubmitted int      `json:"FirstSubmitted,omitempty"`
+	LastModified   int      `json:"LastModified,omitempty"`
+	Depends        []string `json:"Depends,omitempty"`
+	MakeDepends    []string `json:"MakeDepends,omitempty"`
+	CheckDepends   []string `json:"CheckDepends,omitempty"`
+	OptDepends     []string `json:"OptDepends,omitempty"`
+	Conflicts      []string `json:"Conflicts,omitempty"`
+	Provides       []string `json:"Provides,omitempty"`
+	Replaces       []string `json:"Replaces,omitempty"`
+	Groups         []string `json:"Groups,omitempty"`
+	License        []string `json:"License,omitempty"`
+	Keywords       []string `json:"Keywords,omitempty"`
+}
+
+// Index of the AUR kept in memory after the first lookup
+type Index struct {
+	Packages []Pkg
+	names    map[string]int
+}
+
+var (
+	loaded  *Index
+	loadErr error
+	once    sync.Once
+)
+
+// Sync downloads the AUR metadata, or reads it from the configured URL or file,
+// and writes the index to the cache dir
+func Sync() error {
+	conf := config.GetConfig()
+	url := conf.UserFile.AurIndexURL
+	if len(url) == 0 {
+		url = DefaultURL
+	}
+	output.Printf("Syncing the AUR index from %s", url)
+
+	packs, err := Fetch(url)
+	if err != nil {
+		return err
+	}
+	if err := Write(filepath.Join(conf.CacheDir, fileName), packs); err != nil {
+		return err
+	}
+	output.Printf("Indexed %d AUR packages", len(packs))
+	return nil
+}
+
+// Fetch reads metadata in the format of packages-meta-ext-v1.json(.gz)
+func Fetch(url string) ([]Pkg, error) {
+	var body io.ReadCloser
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		body = resp.Body
+	} else {
+		file, err := os.Open(strings.TrimPrefix(url, "file://"))
+		if err != nil {
+			return nil, err
+		}
+		body = file
+	}
+	defer body.Close()
+
+	r, err := decompress(body)
+	if err != nil {
+		return nil, err
+	}
+	packs := []Pkg{}
+	if err := json.NewDecoder(r).Decode(&packs); err != nil {
+		return nil, err
+	}
+	return packs, nil
+}
+
+// decompress gunzips r if it starts with the gzip magic number
+func decompress(r io.Reader) (io.Reader, error) {
+	buf := bufio.NewReader(r)
+	if magic, err := buf.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buf)
+	}
+	return buf, nil
+}
+
+// Write saves packs as a gzipped index, replacing any old one at once
+func Write(path string, packs []Pkg) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(file)
+	if err := json.NewEncoder(gz).Encode(packs); err != nil {
+		file.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Read loads an index written by Write
+func Read(path string) (*Index, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r, err := decompress(file)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{}
+	if err := json.NewDecoder(r).Decode(&idx.Packages); err != nil {
+		return nil, err
+	}
+	idx.names = make(map[string]int, len(idx.Packages))
+	for i, pack := range idx.Packages {
+		idx.names[pack.Name] = i
+	}
+	return idx, nil
+}
+
+// Fresh checks if the index in the cache dir is younger than the configured TTL
+func Fresh() bool {
+	conf := config.GetConfig()
+	info, err := os.Stat(filepath.Join(conf.CacheDir, fileName))
+	if err != nil {
+		return false
+	}
+	ttl := time.Duration(conf.UserFile.AurIndexTTL) * time.Hour
+	return time.Since(info.ModTime()) < ttl
+}
+
+// fresh returns the index if it can be used instead of the RPC
+func fresh() *Index {
+	if !Fresh() {
+		return nil
+	}
+	once.Do(func() {
+		loaded, loadErr = Read(filepath.Join(config.GetConfig().CacheDir, fileName))
+		if loadErr != nil {
+			output.PrintErr("Couldn't read the AUR index, using the RPC: %s", loadErr)
+		}
+	})
+	return loaded
+}
+
+// Names returns the name of every package in the index, or nil if it isn't fresh
+func Names() []string {
+	idx := fresh()
+	if idx == nil {
+		return nil
+	}
+	names := make([]string, 0, len(idx.Packages))
+	for _, pack := range idx.Packages {
+		names = append(names, pack.Name)
+	}
+	return names
+}
+
+// Info looks up packages by name, from the index when it's fresh
+func Info(names []string) ([]Pkg, error) {
+	if idx := fresh(); idx != nil {
+		return idx.Info(names), nil
+	}
+	return rpcInfo(names)
+}
+
+// Search searches by a field like the RPC does, from the index when it's fresh.
+// by is one of the RPC's fields, name-desc if empty
+func Search(query, by string) ([]Pkg, error) {
+	if idx := fresh(); idx != nil {
+		return idx.Search(query, by), nil
+	}
+	return rpcSearch(query, by)
+}
+
+// Info returns the packages found with the given names
+func (idx *Index) Info(names []string) []Pkg {
+	out := []Pkg{}
+	for _, name := range names {
+		if i, ok := idx.names[name]; ok {
+			out = append(out, idx.Packages[i])
+		}
+	}
+	return out
+}
+
+// Search matches packages like the RPC, without its minimum query length
+func (idx *Index) Search(query, by string) []Pkg {
+	query = strings.ToLower(query)
+	out := []Pkg{}
+	for _, pack := range idx.Packages {
+		if pack.match(query, by) {
+			out = append(out, pack)
+		}
+	}
+	return out
+}
+
+func (pack *Pkg) match(query, by string) bool {
+	var deps []string
+	switch by {
+	case "", "name-desc":
+		return strings.Contains(strings.ToLower(pack.Name), query) ||
+			strings.Contains(strings.ToLower(pack.Description), query)
+	case "name":
+		return strings.Contains(strings.ToLower(pack.Name), query)
+	case "maintainer":
+		return strings.ToLower(pack.Maintainer) == query
+	case "depends":
+		deps = pack.Depends
+	case "makedepends":
+		deps = pack.MakeDepends
+	case "optdepends":
+		deps = pack.OptDepends
+	case "checkdepends":
+		deps = pack.CheckDepends
+	case "provides":
+		if pack.Name == query {
+			return true
+		}
+		deps = pack.Provides
+	}
+	for _, dep := range deps {
+		if DepName(dep) == query {
+			return true
+		}
+	}
+	return false
+}
+
+// DepName strips the version and description from a dependency, like foo>=1.0: bar
+func DepName(dep string) string {
+	if i := strings.IndexAny(dep, "<>=:"); i >= 0 {
+		dep = dep[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(dep))
+}
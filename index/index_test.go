@@ -0,0 +1,110 @@
+package index
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ericm/yup/config"
+	"github.com/mikkeloscar/aur"
+)
+
+const meta = `[
+	{"ID": 1, "Name": "yup", "PackageBase": "yup", "Version": "1.1.8-1", "Description": "Arch Linux AUR Helper with ncurses functionality",
+		"Maintainer": "ericm", "NumVotes": 40, "Popularity": 0.5, "Depends": ["pacman>=5.2", "ncurses"], "URLPath": "/cgit/aur.git/snapshot/yup.tar.gz"},
+	{"ID": 2, "Name": "yup-bin", "PackageBase": "yup-bin", "Version": "1.1.8-1", "Description": "Prebuilt yup",
+		"Maintainer": "ericm", "Provides": ["yup=1.1.8"], "Conflicts": ["yup"]},
+	{"ID": 3, "Name": "go-tools", "PackageBase": "go-tools", "Version": "2:1.0-1", "Description": "Developer tools for Go",
+		"Maintainer": null, "MakeDepends": ["go"]}
+]`
+
+func TestSync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yup-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The dump is gzipped when downloaded
+	source := filepath.Join(dir, "packages-meta-ext-v1.json.gz")
+	file, err := os.Create(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte(meta))
+	gz.Close()
+	file.Close()
+
+	config.SetConfig(&config.Config{CacheDir: dir, UserFile: config.File{AurIndexURL: "file://" + source, AurIndexTTL: 1}})
+	if Fresh() {
+		t.Fatal("index is fresh before syncing")
+	}
+	if err := Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if !Fresh() {
+		t.Fatal("index isn't fresh after syncing")
+	}
+
+	packs, err := Info([]string{"yup-bin", "missing", "yup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(packs) != 2 || packs[0].Name != "yup-bin" || packs[1].Depends[1] != "ncurses" {
+		t.Errorf("got info %+v", packs)
+	}
+
+	tests := []struct {
+		query, by string
+		want      []string
+	}{
+		{"yup", "", []string{"yup", "yup-bin"}},
+		{"go", "name", []string{"go-tools"}},
+		{"tools for", "name-desc", []string{"go-tools"}},
+		{"ericm", "maintainer", []string{"yup", "yup-bin"}},
+		{"pacman", "depends", []string{"yup"}},
+		{"go", "makedepends", []string{"go-tools"}},
+		{"yup", "provides", []string{"yup", "yup-bin"}},
+		// No minimum query length
+		{"y", "name", []string{"yup", "yup-bin"}},
+	}
+	for _, test := range tests {
+		packs, err := Search(test.query, test.by)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, pack := range packs {
+			names = append(names, pack.Name)
+		}
+		if len(names) != len(test.want) {
+			t.Errorf("%s by %s: got %v, want %v", test.query, test.by, names, test.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != test.want[i] {
+				t.Errorf("%s by %s: got %v, want %v", test.query, test.by, names, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRPCStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	defer func(url string) { aur.AURURL = url }(aur.AURURL)
+	aur.AURURL = server.URL + "/rpc.php?"
+	if _, err := rpcInfo([]string{"yup"}); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("got error %v", err)
+	}
+}
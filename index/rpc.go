@@ -0,0 +1,63 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mikkeloscar/aur"
+)
+
+// client is used for the RPC and the metadata download.
+// The timeout leaves time to download the whole metadata on a slow connection
+var client = &http.Client{Timeout: time.Minute}
+
+type rpcResponse struct {
+	Error   string `json:"error"`
+	Results []Pkg  `json:"results"`
+}
+
+// rpc queries the AUR RPC v5.
+// aur.SearchBy is missing some fields, such as provides, and aur.Pkg co-maintainers
+func rpc(v url.Values) ([]Pkg, error) {
+	v.Set("v", "5")
+	resp, err := client.Get(aur.AURURL + v.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", aur.AURURL, resp.Status)
+	}
+
+	result := rpcResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Error) > 0 {
+		return nil, errors.New(result.Error)
+	}
+	return result.Results, nil
+}
+
+func rpcInfo(names []string) ([]Pkg, error) {
+	v := url.Values{}
+	v.Set("type", "info")
+	for _, name := range names {
+		v.Add("arg[]", name)
+	}
+	return rpc(v)
+}
+
+func rpcSearch(query, by string) ([]Pkg, error) {
+	v := url.Values{}
+	v.Set("type", "search")
+	v.Set("arg", query)
+	if len(by) > 0 {
+		v.Set("by", by)
+	}
+	return rpc(v)
+}
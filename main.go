@@ -7,7 +7,6 @@ import (
 	"runtime"
 
 	"github.com/ericm/yup/config"
-	"github.com/ericm/yup/search"
 
 	"github.com/ericm/yup/cmd"
 	"github.com/ericm/yup/output"
@@ -35,7 +34,6 @@ func main() {
 		os.Exit(1)
 	}
 
-	exitError(search.Init())
 	exitError(paths())
 	exitError(makePaths())
 	exitError(config.ReadConfigFile(cmd.Version))
@@ -0,0 +1,257 @@
+package news
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/output"
+)
+
+// Item represents a single news post
+type Item struct {
+	Title       string
+	Link        string
+	Description string
+	Published   time.Time
+}
+
+// RSS and Atom are decoded together; only one of Items or Entries is filled
+type feed struct {
+	Items   []rssItem   `xml:"channel>item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+}
+
+var dateFormats = []string{time.RFC1123Z, time.RFC1123, time.RFC3339, time.RFC822Z, time.RFC822}
+
+// How far back news is shown on the first run, if pacman's log has no system upgrade
+const firstRunWindow = 90 * 24 * time.Hour
+
+// Feeds are fetched before every upgrade, so a slow one mustn't hold it up
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Check shows unread news and asks whether to continue with the upgrade
+func Check() (bool, error) {
+	conf := config.GetConfig().UserFile
+	if !conf.CheckNews || len(conf.NewsURL) == 0 {
+		return true, nil
+	}
+
+	items, err := Fetch(conf.NewsURL)
+	if err != nil {
+		// Don't block upgrades on an unreachable feed
+		output.PrintErr("Couldn't fetch news: %s", err)
+		return true, nil
+	}
+
+	state, err := config.ReadState()
+	if err != nil {
+		return false, err
+	}
+	seen := state.NewsSeen
+	if seen.IsZero() {
+		// On the first run, news since the last system upgrade may still need acting on
+		seen = lastUpgrade(config.GetConfig().Pacman.LogFile)
+		if seen.IsZero() {
+			seen = time.Now().Add(-firstRunWindow)
+		}
+	}
+	unread := Unread(items, seen)
+	if len(unread) == 0 {
+		if state.NewsSeen.IsZero() && len(items) > 0 {
+			return true, markSeen(items[0].Published)
+		}
+		return true, nil
+	}
+
+	output.Printf("There is unread news from %s:", conf.NewsURL)
+	for _, item := range unread {
+		fmt.Printf("\n\033[1m%s\033[0m \033[2m%s\033[0m\n", item.Title, item.Published.Format("2006-01-02"))
+		if len(item.Link) > 0 {
+			fmt.Printf("    \033[4m%s\033[0m\n", item.Link)
+		}
+		for _, line := range strings.Split(stripHTML(item.Description), "\n") {
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+	fmt.Print("\n")
+
+	output.PrintIn("Continue with the system upgrade? (y/N)")
+	scanner := bufio.NewReader(os.Stdin)
+	in, _ := scanner.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(in)) != "y" {
+		return false, nil
+	}
+
+	return true, markSeen(unread[0].Published)
+}
+
+// lastUpgrade returns when pacman last started a system upgrade, from its log file,
+// or the zero time if it can't tell
+func lastUpgrade(logFile string) time.Time {
+	file, err := os.Open(logFile)
+	if err != nil {
+		return time.Time{}
+	}
+	defer file.Close()
+	return parseLog(file)
+}
+
+// parseLog returns the time of the last system upgrade in a pacman log
+func parseLog(r io.Reader) time.Time {
+	last := time.Time{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		end := strings.Index(line, "]")
+		if !strings.HasPrefix(line, "[") || end < 0 || !strings.Contains(line, "starting full system upgrade") {
+			continue
+		}
+		// Newer pacman logs the offset, older versions only the local minute
+		stamp := line[1:end]
+		if t, err := time.Parse("2006-01-02T15:04:05-0700", stamp); err == nil {
+			last = t
+		} else if t, err := time.ParseInLocation("2006-01-02 15:04", stamp, time.Local); err == nil {
+			last = t
+		}
+	}
+	return last
+}
+
+// markSeen records that news up to published has been read
+func markSeen(published time.Time) error {
+	return config.UpdateState(func(state *config.State) {
+		state.NewsSeen = published
+	})
+}
+
+// Fetch reads a feed from a URL or local file
+func Fetch(url string) ([]Item, error) {
+	var body io.ReadCloser
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		resp, err := client.Get(url)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+		}
+		body = resp.Body
+	} else {
+		file, err := os.Open(strings.TrimPrefix(url, "file://"))
+		if err != nil {
+			return nil, err
+		}
+		body = file
+	}
+	defer body.Close()
+
+	return Parse(body)
+}
+
+// Parse decodes an RSS or Atom feed, newest items first
+func Parse(r io.Reader) ([]Item, error) {
+	var f feed
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+
+	items := []Item{}
+	for _, it := range f.Items {
+		items = append(items, Item{
+			Title:       strings.TrimSpace(it.Title),
+			Link:        strings.TrimSpace(it.Link),
+			Description: it.Description,
+			Published:   parseDate(it.PubDate),
+		})
+	}
+	for _, entry := range f.Entries {
+		item := Item{
+			Title:       strings.TrimSpace(entry.Title),
+			Description: entry.Summary,
+			Published:   parseDate(entry.Published),
+		}
+		if len(item.Description) == 0 {
+			item.Description = entry.Content
+		}
+		if item.Published.IsZero() {
+			item.Published = parseDate(entry.Updated)
+		}
+		for _, link := range entry.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				item.Link = link.Href
+				break
+			}
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Published.After(items[j].Published)
+	})
+	return items, nil
+}
+
+// Unread returns the items published after seen
+func Unread(items []Item, seen time.Time) []Item {
+	out := []Item{}
+	for _, item := range items {
+		if item.Published.After(seen) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func parseDate(date string) time.Time {
+	date = strings.TrimSpace(date)
+	for _, format := range dateFormats {
+		if t, err := time.Parse(format, date); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var (
+	breakRe = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</li>`)
+	tagRe   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// stripHTML turns a feed description into plain text
+func stripHTML(desc string) string {
+	desc = breakRe.ReplaceAllString(desc, "\n")
+	desc = tagRe.ReplaceAllString(desc, "")
+	return html.UnescapeString(desc)
+}
@@ -0,0 +1,97 @@
+package news
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const rss = `<?xml version="1.0" encoding="utf-8"?>
+<rss version="2.0"><channel><title>Arch Linux: Recent news updates</title>
+<item><title>Older news</title><link>https://archlinux.org/news/older/</link>
+<description>&lt;p&gt;Nothing to do&lt;/p&gt;</description><pubDate>Mon, 01 Jun 2020 10:00:00 +0000</pubDate></item>
+<item><title>Manual intervention required</title><link>https://archlinux.org/news/manual/</link>
+<description>&lt;p&gt;Run &lt;code&gt;pacman -Syu --overwrite&lt;/code&gt;&lt;/p&gt;</description><pubDate>Tue, 02 Jun 2020 10:00:00 +0000</pubDate></item>
+</channel></rss>`
+
+const atom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>News</title>
+<entry><title>Atom entry</title><link href="https://example.org/entry"/>
+<updated>2020-06-03T10:00:00Z</updated><summary>Summary</summary></entry>
+</feed>`
+
+func TestParse(t *testing.T) {
+	items, err := Parse(strings.NewReader(rss))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Title != "Manual intervention required" {
+		t.Fatalf("items not parsed newest first: %+v", items)
+	}
+	if got := stripHTML(items[0].Description); got != "Run pacman -Syu --overwrite\n" {
+		t.Errorf("description not stripped: %q", got)
+	}
+
+	items, err = Parse(strings.NewReader(atom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Link != "https://example.org/entry" || items[0].Published.IsZero() {
+		t.Errorf("atom entry not parsed: %+v", items)
+	}
+}
+
+func TestUnread(t *testing.T) {
+	items, _ := Parse(strings.NewReader(rss))
+	seen := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	if unread := Unread(items, seen); len(unread) != 1 || unread[0].Title != "Manual intervention required" {
+		t.Errorf("got %+v", unread)
+	}
+	if unread := Unread(items, time.Time{}); len(unread) != 2 {
+		t.Errorf("expected all items to be unread, got %d", len(unread))
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(atom))
+	}))
+	defer server.Close()
+
+	if items, err := Fetch(server.URL); err != nil || len(items) != 1 {
+		t.Errorf("fetch from server: %v %+v", err, items)
+	}
+
+	file, err := ioutil.TempFile("", "yup-news")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(rss)
+	file.Close()
+
+	if items, err := Fetch("file://" + file.Name()); err != nil || len(items) != 2 {
+		t.Errorf("fetch from file: %v %+v", err, items)
+	}
+}
+
+func TestParseLog(t *testing.T) {
+	log := `[2020-05-30T09:00:00+0000] [PACMAN] Running 'pacman -Syu'
+[2020-05-30T09:00:01+0000] [PACMAN] starting full system upgrade
+[2020-05-30T09:01:00+0000] [ALPM] upgraded glibc (2.31-2 -> 2.31-3)
+[2020-06-01T12:00:00+0000] [PACMAN] Running 'pacman -S vim'
+[2020-06-01 12:30] [PACMAN] starting full system upgrade
+[2020-06-02T08:00:00+0000] [ALPM] installed nano (4.9.3-1)
+`
+	want := time.Date(2020, 6, 1, 12, 30, 0, 0, time.Local)
+	if got := parseLog(strings.NewReader(log)); !got.Equal(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	if got := parseLog(strings.NewReader("[2020-06-01T12:00:00+0000] [ALPM] installed nano (4.9.3-1)\n")); !got.IsZero() {
+		t.Errorf("no upgrade: got %s", got)
+	}
+}
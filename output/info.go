@@ -0,0 +1,99 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InfoField is a line of package info, like those of pacman -Si
+type InfoField struct {
+	Name  string
+	Value string
+}
+
+// InfoFields lists every known field of a package
+func InfoFields(pack Package) []InfoField {
+	fields := []InfoField{
+		{"Repository", pack.Repo},
+		{"Name", pack.Name},
+	}
+	if pack.Aur {
+		fields = append(fields, InfoField{"Package Base", pack.Base})
+	}
+	fields = append(fields,
+		InfoField{"Version", pack.Version},
+		InfoField{"Description", pack.Description},
+		InfoField{"URL", pack.Upstream},
+		InfoField{"Licenses", list(pack.License)},
+		InfoField{"Groups", list(pack.Groups)},
+		InfoField{"Provides", list(pack.Provides)},
+		InfoField{"Depends On", deps(pack.Depends)},
+		InfoField{"Optional Deps", deps(pack.OptDepends)},
+		InfoField{"Make Deps", deps(pack.MakeDepends)},
+		InfoField{"Check Deps", deps(pack.CheckDepends)},
+		InfoField{"Conflicts With", list(pack.Conflicts)},
+		InfoField{"Replaces", list(pack.Replaces)},
+	)
+	if !pack.Aur {
+		return append(fields,
+			InfoField{"Download Size", pack.DownloadSize},
+			InfoField{"Installed Size", pack.InstalledSize},
+			InfoField{"Packager", pack.Maintainer},
+		)
+	}
+
+	maintainer := pack.Maintainer
+	if len(maintainer) == 0 {
+		maintainer = "None (orphan)"
+	}
+	outOfDate := "No"
+	if pack.OutOfDate != 0 {
+		outOfDate = date(pack.OutOfDate)
+	}
+	return append(fields,
+		InfoField{"Keywords", list(pack.Keywords)},
+		InfoField{"Maintainer", maintainer},
+		InfoField{"Co-Maintainers", list(pack.CoMaintainers)},
+		InfoField{"Votes", strconv.Itoa(pack.Votes)},
+		InfoField{"Popularity", strconv.FormatFloat(pack.Popularity, 'f', 2, 64)},
+		InfoField{"First Submitted", date(pack.FirstSubmitted)},
+		InfoField{"Last Modified", date(pack.LastModified)},
+		InfoField{"Out-of-date", outOfDate},
+	)
+}
+
+// PrintInfo prints every field of a package like pacman -Si
+func PrintInfo(pack Package) {
+	for _, field := range InfoFields(pack) {
+		fmt.Printf("\033[1m%-16s:\033[0m %s\n", field.Name, field.Value)
+	}
+	fmt.Println()
+}
+
+func list(items []string) string {
+	if len(items) == 0 {
+		return "None"
+	}
+	return strings.Join(items, "  ")
+}
+
+func deps(items []Dependency) string {
+	names := []string{}
+	for _, dep := range items {
+		if dep.Installed {
+			names = append(names, dep.Name+" [installed]")
+		} else {
+			names = append(names, dep.Name)
+		}
+	}
+	return list(names)
+}
+
+func date(unix int) string {
+	if unix == 0 {
+		return "None"
+	}
+	return time.Unix(int64(unix), 0).Format("Mon 02 Jan 2006 03:04:05 PM MST")
+}
@@ -0,0 +1,131 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// JSONPackage is a package in --json output. The schema is documented in the README
+type JSONPackage struct {
+	Name             string   `json:"name"`
+	Repo             string   `json:"repo"`
+	Version          string   `json:"version"`
+	Description      string   `json:"description"`
+	Aur              bool     `json:"aur"`
+	Installed        bool     `json:"installed"`
+	InstalledVersion string   `json:"installed_version"`
+	InstalledSize    int      `json:"installed_size"`
+	URL              string   `json:"url"`
+	Maintainer       string   `json:"maintainer"`
+	Votes            int      `json:"votes"`
+	Popularity       float64  `json:"popularity"`
+	OutOfDate        int      `json:"out_of_date"`
+	Groups           []string `json:"groups"`
+	Provides         []string `json:"provides"`
+
+	// Only with -Si
+	Base           string           `json:"base,omitempty"`
+	CoMaintainers  []string         `json:"co_maintainers,omitempty"`
+	FirstSubmitted int              `json:"first_submitted,omitempty"`
+	LastModified   int              `json:"last_modified,omitempty"`
+	License        []string         `json:"license,omitempty"`
+	Keywords       []string         `json:"keywords,omitempty"`
+	Depends        []JSONDependency `json:"depends,omitempty"`
+	MakeDepends    []JSONDependency `json:"make_depends,omitempty"`
+	CheckDepends   []JSONDependency `json:"check_depends,omitempty"`
+	OptDepends     []JSONDependency `json:"opt_depends,omitempty"`
+	Conflicts      []string         `json:"conflicts,omitempty"`
+	Replaces       []string         `json:"replaces,omitempty"`
+}
+
+// JSONDependency is a dependency in --json output
+type JSONDependency struct {
+	Name      string `json:"name"`
+	Installed bool   `json:"installed"`
+}
+
+// JSONUpdate is a pending update in --json output
+type JSONUpdate struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	NewVersion string `json:"new_version"`
+}
+
+// JSONPackages is the document printed by searches, -Si and -Qos
+type JSONPackages struct {
+	Packages []JSONPackage `json:"packages"`
+}
+
+// JSONPlan is the document printed instead of running a system upgrade
+type JSONPlan struct {
+	Repo  []JSONUpdate `json:"repo"`
+	Aur   []JSONUpdate `json:"aur"`
+	Newer []JSONUpdate `json:"newer"`
+}
+
+// ToJSON converts packages to their --json form.
+// info adds the fields only shown by -Si
+func ToJSON(packs []Package, info bool) JSONPackages {
+	doc := JSONPackages{Packages: []JSONPackage{}}
+	for _, pack := range packs {
+		jsonPack := JSONPackage{
+			Name:             pack.Name,
+			Repo:             pack.Repo,
+			Version:          pack.Version,
+			Description:      pack.Description,
+			Aur:              pack.Aur,
+			Installed:        pack.Installed,
+			InstalledVersion: pack.InstalledVersion,
+			InstalledSize:    pack.InstalledSizeInt,
+			URL:              pack.Upstream,
+			Maintainer:       pack.Maintainer,
+			Votes:            pack.Votes,
+			Popularity:       pack.Popularity,
+			OutOfDate:        pack.OutOfDate,
+			Groups:           nonNil(pack.Groups),
+			Provides:         nonNil(pack.Provides),
+		}
+		if info {
+			jsonPack.Base = pack.Base
+			jsonPack.CoMaintainers = pack.CoMaintainers
+			jsonPack.FirstSubmitted = pack.FirstSubmitted
+			jsonPack.LastModified = pack.LastModified
+			jsonPack.License = pack.License
+			jsonPack.Keywords = pack.Keywords
+			jsonPack.Depends = jsonDeps(pack.Depends)
+			jsonPack.MakeDepends = jsonDeps(pack.MakeDepends)
+			jsonPack.CheckDepends = jsonDeps(pack.CheckDepends)
+			jsonPack.OptDepends = jsonDeps(pack.OptDepends)
+			jsonPack.Conflicts = pack.Conflicts
+			jsonPack.Replaces = pack.Replaces
+		}
+		doc.Packages = append(doc.Packages, jsonPack)
+	}
+	return doc
+}
+
+// PrintJSON writes a document to stdout
+func PrintJSON(doc interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// nonNil keeps empty lists as [] rather than null
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
+func jsonDeps(deps []Dependency) []JSONDependency {
+	if len(deps) == 0 {
+		return nil
+	}
+	out := []JSONDependency{}
+	for _, dep := range deps {
+		out = append(out, JSONDependency{Name: dep.Name, Installed: dep.Installed})
+	}
+	return out
+}
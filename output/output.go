@@ -2,9 +2,9 @@ package output
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"strconv"
 	"strings"
 )
 
@@ -33,16 +33,47 @@ type Package struct {
 	SortValue        float64
 	OutOfDate        int
 	Upstream         string
+	Votes            int
+	Popularity       float64
+	Maintainer       string
+	Groups           []string
+	Provides         []string
+	Base             string
+	CoMaintainers    []string
+	FirstSubmitted   int
+	LastModified     int
+	License          []string
+	Keywords         []string
+	Depends          []Dependency
+	MakeDepends      []Dependency
+	CheckDepends     []Dependency
+	OptDepends       []Dependency
+	Conflicts        []string
+	Replaces         []string
+}
+
+// Dependency of a package and whether it's satisfied locally
+type Dependency struct {
+	Name      string
+	Installed bool
+}
+
+// Where messages are printed. Stdout unless it's used for JSON
+var messages io.Writer = os.Stdout
+
+// MessagesTo sends messages from Printf, PrintIn, PrintErr and PrintL to w
+func MessagesTo(w io.Writer) {
+	messages = w
 }
 
 // Printf arrow wrapper for fmt
 func Printf(format string, a ...interface{}) {
-	fmt.Printf("%s %s\n", ARROW, fmt.Sprintf(format, a...))
+	fmt.Fprintf(messages, "%s %s\n", ARROW, fmt.Sprintf(format, a...))
 }
 
 // PrintIn styles stdout for input from stdin
 func PrintIn(format string, a ...interface{}) {
-	fmt.Printf("%s \033[92m%s:\033[0m ", ARROWIN, fmt.Sprintf(format, a...))
+	fmt.Fprintf(messages, "%s \033[92m%s:\033[0m ", ARROWIN, fmt.Sprintf(format, a...))
 }
 
 // Errorf arrow wrapper for fmt
@@ -52,24 +83,16 @@ func Errorf(format string, a ...interface{}) error {
 
 // PrintErr prints Errorf
 func PrintErr(format string, a ...interface{}) {
-	fmt.Println(Errorf(format, a...))
+	fmt.Fprintln(messages, Errorf(format, a...))
 }
 
 // PrintL - prints line break
 func PrintL() {
-	// Get tty size
-	tty := exec.Command("stty", "size")
-	tty.Stdin = os.Stdin
-	out, _ := tty.Output()
-	str := strings.Split(string(out), " ")
-	var n int
-	if len(str) > 1 {
-		n, _ = strconv.Atoi(strings.ReplaceAll(str[1], "\n", ""))
-	} else {
+	n := Columns()
+	if n == 0 {
 		n = 40
 	}
-
-	fmt.Printf("\033[34m%s\033[0m\n", strings.Repeat("=", n))
+	fmt.Fprintf(messages, "\033[34m%s\033[0m\n", strings.Repeat("=", n))
 }
 
 // SetStd sets cmd's Stdout, Stderr and Stdin to the OS's
@@ -77,39 +100,59 @@ func SetStd(cmd *exec.Cmd) {
 	cmd.Stdout, cmd.Stdin, cmd.Stderr = os.Stdout, os.Stdin, os.Stderr
 }
 
+// RepoColor wraps a repo name in its theme colour for printing
+func RepoColor(repo string) string {
+	return ANSI(theme.Repo(repo)) + repo + "\033[0m"
+}
+
 // PrintPackage in formatted view
 func PrintPackage(pack Package, mode ...string) string {
 	outdated := ""
 	if pack.Version != pack.InstalledVersion {
-		outdated = fmt.Sprintf(", (\033[1m\033[95mOUTDATED\033[0m %s)", pack.InstalledVersion)
+		outdated = fmt.Sprintf(", (\033[1m%sOUTDATED\033[0m %s)", ANSI(theme.Outdated), pack.InstalledVersion)
 	}
 
-	out := ""
+	// Groups follow the version like in pacman -Ss
+	version := pack.Version
+	if len(pack.Groups) > 0 {
+		version = fmt.Sprintf("%s (%s)", version, strings.Join(pack.Groups, " "))
+	}
+
+	repo := RepoColor(pack.Repo)
+	width := Columns()
 
 	if len(mode) > 0 {
 		switch mode[0] {
 		case "sso":
 			// yup -Sso mode
-			fmt.Printf("\033[2m/\033[0m\033[1m%s\033[0m %s, (\033[95m\033[1mInstall Size: %s\033[0m)%s\n    %s\n",
-				pack.Name, pack.Version, pack.InstalledSize, outdated, pack.Description)
+			head := fmt.Sprintf("\033[2m/\033[0m\033[1m%s\033[0m %s, (\033[95m\033[1mInstall Size: %s\033[0m)%s",
+				pack.Name, pack.Version, pack.InstalledSize, outdated)
+			fmt.Print(layout(head, pack.Description, width))
 			return ""
 		case "def":
+			// Numbered lists take 5 columns before the package
+			if width > 5 {
+				width -= 5
+			}
 		case "ncurses":
 			pack.Description = fmt.Sprintf(" - %s", pack.Description)
+			width = 0
 		}
 	}
 
+	head := ""
 	if pack.Installed {
 		if pack.InstalledSize == "" {
-			out = fmt.Sprintf("%s\033[2m/\033[0m\033[1m%s\033[0m %s (\033[1m\033[95mINSTALLED\033[0m)%s\n    %s\n",
-				pack.Repo, pack.Name, pack.Version, outdated, pack.Description)
+			head = fmt.Sprintf("%s\033[2m/\033[0m\033[1m%s\033[0m %s (\033[1m%sINSTALLED\033[0m)%s",
+				repo, pack.Name, version, ANSI(theme.Installed), outdated)
 		} else {
-			out = fmt.Sprintf("%s\033[2m/\033[0m\033[1m%s\033[0m %s (\033[1m\033[95mINSTALLED\033[0m), (Installed Size: %s)%s\n    %s\n",
-				pack.Repo, pack.Name, pack.Version, pack.InstalledSize, outdated, pack.Description)
+			head = fmt.Sprintf("%s\033[2m/\033[0m\033[1m%s\033[0m %s (\033[1m%sINSTALLED\033[0m), (Installed Size: %s)%s",
+				repo, pack.Name, version, ANSI(theme.Installed), pack.InstalledSize, outdated)
 		}
 	} else {
-		out = fmt.Sprintf("%s\033[2m/\033[0m\033[1m%s\033[0m %s\n    %s\n", pack.Repo, pack.Name, pack.Version, pack.Description)
+		head = fmt.Sprintf("%s\033[2m/\033[0m\033[1m%s\033[0m %s", repo, pack.Name, version)
 	}
+	out := layout(head, pack.Description, width)
 
 	// Handle ncurses
 	if len(mode) > 0 && mode[0] == "ncurses" {
@@ -119,3 +162,37 @@ func PrintPackage(pack Package, mode ...string) string {
 
 	return ""
 }
+
+// layout puts a package's description under its first line, wrapped to width columns.
+// Narrow terminals get both on one line, cut at the edge. A width of 0 leaves the description as it is,
+// for when stdout isn't a terminal
+func layout(head, desc string, width int) string {
+	switch {
+	case width == 0:
+		return fmt.Sprintf("%s\n    %s\n", head, desc)
+	case width < CompactWidth:
+		return Truncate(fmt.Sprintf("%s \033[2m- %s", head, desc), width, "..") + "\033[0m\n"
+	}
+	return fmt.Sprintf("%s\n    %s\n", head, strings.Join(Wrap(desc, width-4), "\n    "))
+}
+
+// FormatSize turns 1024 into 1.00 KiB
+func FormatSize(dataI int64) string {
+	b := float32(1024)
+	data := float32(dataI)
+	i := 0
+	for data != 0 && data > 1024 {
+		data /= b
+		i++
+	}
+	switch i {
+	case 1:
+		return fmt.Sprintf("%.2f %s", data, "KiB")
+	case 2:
+		return fmt.Sprintf("%.2f %s", data, "MiB")
+	case 3:
+		return fmt.Sprintf("%.2f %s", data, "GiB")
+	default:
+		return fmt.Sprintf("%.2f B", data)
+	}
+}
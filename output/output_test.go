@@ -1 +1,78 @@
-package output
\ No newline at end of file
+package output
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInfoFields(t *testing.T) {
+	pack := Package{
+		Aur:           true,
+		Repo:          "aur",
+		Name:          "yup",
+		Base:          "yup",
+		Depends:       []Dependency{{Name: "pacman>=5.2", Installed: true}, {Name: "ncurses"}},
+		CoMaintainers: []string{"a", "b"},
+	}
+	want := map[string]string{
+		"Repository":     "aur",
+		"Package Base":   "yup",
+		"Depends On":     "pacman>=5.2 [installed]  ncurses",
+		"Make Deps":      "None",
+		"Maintainer":     "None (orphan)",
+		"Co-Maintainers": "a  b",
+		"Out-of-date":    "No",
+	}
+	for _, field := range InfoFields(pack) {
+		if value, ok := want[field.Name]; ok && value != field.Value {
+			t.Errorf("%s: got %q, want %q", field.Name, field.Value, value)
+		}
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	packs := []Package{{Name: "yup", Repo: "aur", Aur: true, Base: "yup", Depends: []Dependency{{Name: "pacman"}}}}
+
+	data, err := json.Marshal(ToJSON(packs, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"packages":[{"name":"yup","repo":"aur","version":"","description":"","aur":true,"installed":false,` +
+		`"installed_version":"","installed_size":0,"url":"","maintainer":"","votes":0,"popularity":0,"out_of_date":0,` +
+		`"groups":[],"provides":[]}]}`
+	if string(data) != want {
+		t.Errorf("got %s", data)
+	}
+
+	doc := ToJSON(packs, true)
+	if doc.Packages[0].Base != "yup" || len(doc.Packages[0].Depends) != 1 {
+		t.Errorf("info fields missing: %+v", doc.Packages[0])
+	}
+}
+
+func TestTheme(t *testing.T) {
+	theme := DefaultTheme()
+	if err := theme.Validate(); err != nil {
+		t.Fatalf("default theme: %s", err)
+	}
+	if got := ANSI(theme.Repo("aur")); got != "\033[91m" {
+		t.Errorf("aur: got %q", got)
+	}
+	if got := ANSI("cyan"); got != "\033[36m" {
+		t.Errorf("cyan: got %q", got)
+	}
+
+	// Unknown repos always get the same colour
+	color := theme.Repo("chaotic-aur")
+	if _, ok := ColorNumber(color); !ok {
+		t.Errorf("chaotic-aur: got unknown colour %q", color)
+	}
+	if again := theme.Repo("chaotic-aur"); again != color {
+		t.Errorf("chaotic-aur: got %q then %q", color, again)
+	}
+
+	theme.Repos["local"] = "purple"
+	if err := theme.Validate(); err == nil {
+		t.Error("purple: expected an error")
+	}
+}
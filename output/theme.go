@@ -0,0 +1,121 @@
+package output
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+// Theme sets the colours of repos and markers in package lists, both printed and in ncurses.
+// Colours are black, red, green, yellow, blue, magenta, cyan and white, optionally prefixed with bright-
+type Theme struct {
+	Repos     map[string]string `json:"repos"`
+	Selected  string            `json:"selected"`
+	Checked   string            `json:"checked"`
+	Installed string            `json:"installed"`
+	Outdated  string            `json:"outdated"`
+}
+
+// Colours in terminal order
+var colors = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// Colours given to repos missing from the theme
+var hashColors = []string{
+	"red", "green", "yellow", "blue", "magenta", "cyan",
+	"bright-red", "bright-green", "bright-yellow", "bright-blue", "bright-magenta", "bright-cyan",
+}
+
+var theme = DefaultTheme()
+
+// DefaultTheme returns yup's colours
+func DefaultTheme() Theme {
+	return Theme{
+		Repos: map[string]string{
+			"aur":       "bright-red",
+			"core":      "bright-magenta",
+			"extra":     "green",
+			"community": "cyan",
+			"multilib":  "yellow",
+			"group":     "bright-blue",
+		},
+		Selected:  "white",
+		Checked:   "magenta",
+		Installed: "bright-magenta",
+		Outdated:  "bright-magenta",
+	}
+}
+
+// SetTheme changes the colours used from now on
+func SetTheme(t Theme) {
+	theme = t
+}
+
+// CurrentTheme returns the colours in use
+func CurrentTheme() Theme {
+	return theme
+}
+
+// ColorNumber returns the terminal colour number of a colour name, 8 to 15 for bright colours
+func ColorNumber(name string) (int, bool) {
+	bright := strings.HasPrefix(name, "bright-")
+	name = strings.TrimPrefix(name, "bright-")
+	for i, color := range colors {
+		if color == name {
+			if bright {
+				return i + 8, true
+			}
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// ANSI returns the escape code setting the foreground to a colour
+func ANSI(name string) string {
+	n, ok := ColorNumber(name)
+	switch {
+	case !ok:
+		return ""
+	case n >= 8:
+		return fmt.Sprintf("\033[%dm", 90+n-8)
+	}
+	return fmt.Sprintf("\033[%dm", 30+n)
+}
+
+// Repo returns the colour of a repo. Repos missing from the theme get one from a hash of their name,
+// so they keep it between runs
+func (t Theme) Repo(repo string) string {
+	if color, ok := t.Repos[repo]; ok {
+		return color
+	}
+	h := fnv.New32a()
+	h.Write([]byte(repo))
+	return hashColors[h.Sum32()%uint32(len(hashColors))]
+}
+
+// Validate checks every colour of the theme
+func (t Theme) Validate() error {
+	repos := []string{}
+	for repo := range t.Repos {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	fields := [][2]string{
+		{"selected", t.Selected},
+		{"checked", t.Checked},
+		{"installed", t.Installed},
+		{"outdated", t.Outdated},
+	}
+	for _, repo := range repos {
+		fields = append(fields, [2]string{"repos." + repo, t.Repos[repo]})
+	}
+	for _, field := range fields {
+		if _, ok := ColorNumber(field[1]); !ok {
+			return fmt.Errorf("theme: %s: unknown colour %q, expected one of %s (optionally bright-)",
+				field[0], field[1], strings.Join(colors, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package output
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// CompactWidth is the terminal width under which packages are listed on a single line
+const CompactWidth = 60
+
+// Characters that take two columns: East Asian wide and fullwidth ones, and emoji
+var wide = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{0x1100, 0x115f, 1},
+		{0x231a, 0x231b, 1},
+		{0x2329, 0x232a, 1},
+		{0x2e80, 0x303e, 1},
+		{0x3041, 0x33ff, 1},
+		{0x3400, 0x4dbf, 1},
+		{0x4e00, 0x9fff, 1},
+		{0xa000, 0xa4cf, 1},
+		{0xa960, 0xa97f, 1},
+		{0xac00, 0xd7a3, 1},
+		{0xf900, 0xfaff, 1},
+		{0xfe10, 0xfe19, 1},
+		{0xfe30, 0xfe6f, 1},
+		{0xff00, 0xff60, 1},
+		{0xffe0, 0xffe6, 1},
+	},
+	R32: []unicode.Range32{
+		{0x1f300, 0x1f64f, 1},
+		{0x1f900, 0x1f9ff, 1},
+		{0x20000, 0x2fffd, 1},
+		{0x30000, 0x3fffd, 1},
+	},
+}
+
+// RuneWidth returns the number of columns a terminal draws r in
+func RuneWidth(r rune) int {
+	switch {
+	case r < 32, r >= 0x7f && r < 0xa0:
+		return 0
+	case r < 0x300:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf), r >= 0x1160 && r <= 0x11ff:
+		// Combining marks and Hangul vowels join the character before them
+		return 0
+	case unicode.Is(wide, r):
+		return 2
+	}
+	return 1
+}
+
+// Width returns the number of columns s takes on a terminal.
+// ANSI escapes take none
+func Width(s string) int {
+	_, _, width := cut(s, -1)
+	return width
+}
+
+// Truncate cuts s to at most width columns, ending it with tail if anything was cut.
+// ANSI escapes are kept but take no space
+func Truncate(s string, width int, tail string) string {
+	if Width(s) <= width {
+		return s
+	}
+	if width < 0 {
+		width = 0
+	}
+	if Width(tail) > width {
+		tail = ""
+	}
+	head, _, _ := cut(s, width-Width(tail))
+	return head + tail
+}
+
+// Wrap splits text into lines of at most width columns, breaking between words when it can
+func Wrap(text string, width int) []string {
+	if width < 1 {
+		return nil
+	}
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		// Hard break words longer than a line, like unspaced CJK text
+		for Width(word) > width {
+			if len(line) > 0 {
+				lines = append(lines, line)
+				line = ""
+			}
+			head, rest, _ := cut(word, width)
+			if len(head) == 0 {
+				// A rune wider than the line still needs one to itself
+				_, size := utf8.DecodeRuneInString(word)
+				head, rest = word[:size], word[size:]
+			}
+			lines = append(lines, head)
+			word = rest
+		}
+		switch {
+		case len(line) == 0:
+			line = word
+		case Width(line)+1+Width(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if len(line) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// cut splits s after at most width columns, or not at all if width is negative.
+// It returns both parts and the width of the first
+func cut(s string, width int) (string, string, int) {
+	w := 0
+	for i := 0; i < len(s); {
+		if n := escape(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		rw := RuneWidth(r)
+		if width >= 0 && w+rw > width {
+			return s[:i], s[i:], w
+		}
+		w += rw
+		i += size
+	}
+	return s, "", w
+}
+
+// escape returns the length of the ANSI escape at the start of s, or 0
+func escape(s string) int {
+	if !strings.HasPrefix(s, "\033[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// TermSize returns the rows and columns of the terminal on stdout
+func TermSize() (int, int, error) {
+	ws := struct{ Row, Col, X, Y uint16 }{}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(), syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0, 0, errno
+	}
+	return int(ws.Row), int(ws.Col), nil
+}
+
+// Columns returns the width of the terminal on stdout, or 0 if stdout isn't one
+func Columns() int {
+	if _, cols, err := TermSize(); err == nil {
+		return cols
+	}
+	return 0
+}
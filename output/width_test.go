@@ -0,0 +1,81 @@
+package output
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+	}{
+		{"yup", 3},
+		{"日本語", 6},
+		{"é", 1},
+		{"\033[1mbold\033[0m", 4},
+		{"🎉 ok", 5},
+	}
+	for _, test := range tests {
+		if got := Width(test.text); got != test.width {
+			t.Errorf("Width(%q): got %d, want %d", test.text, got, test.width)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		tail  string
+		want  string
+	}{
+		{"short", 10, "..", "short"},
+		{"A fast text editor", 8, "..", "A fast.."},
+		// Wide characters aren't split
+		{"日本語のテキスト", 7, "..", "日本.."},
+		{"日本語", 5, "", "日本"},
+		{"\033[1mbold\033[0m text", 6, "", "\033[1mbold\033[0m t"},
+		{"abc", 1, "..", "a"},
+	}
+	for _, test := range tests {
+		if got := Truncate(test.text, test.width, test.tail); got != test.want {
+			t.Errorf("Truncate(%q, %d): got %q, want %q", test.text, test.width, got, test.want)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"A fast text editor", 10, []string{"A fast", "text", "editor"}},
+		{"https://example.com/a/long/url ok", 12, []string{"https://exam", "ple.com/a/lo", "ng/url ok"}},
+		{"日本語 の テキスト", 6, []string{"日本語", "の", "テキス", "ト"}},
+		{"日本語 の テキスト", 9, []string{"日本語 の", "テキスト"}},
+		{"日本", 1, []string{"日", "本"}},
+	}
+	for _, test := range tests {
+		if got := Wrap(test.text, test.width); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Wrap(%q, %d): got %q, want %q", test.text, test.width, got, test.want)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	head := "\033[1mextra/yup\033[0m 1.0"
+	if got := layout(head, "An AUR helper", 0); got != head+"\n    An AUR helper\n" {
+		t.Errorf("not a terminal: got %q", got)
+	}
+	desc := "Pacman wrapper and AUR helper with smart search results, ncurses and a lot more"
+	if got := layout(head, desc, 64); got != head+"\n    Pacman wrapper and AUR helper with smart search results,\n    ncurses and a lot more\n" {
+		t.Errorf("wrapped: got %q", got)
+	}
+	// Narrow terminals get a single line
+	if got := layout(head, "An AUR helper", 24); Width(got) != 24 || got[len(got)-1] != '\n' {
+		t.Errorf("compact: got %q", got)
+	}
+}
@@ -0,0 +1,169 @@
+package rebuild
+
+import (
+	"bufio"
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default library directories searched by the dynamic linker
+var (
+	libDirs64 = []string{"/usr/lib", "/lib", "/usr/lib64", "/lib64"}
+	libDirs32 = []string{"/usr/lib32", "/lib32"}
+)
+
+// resolver finds the libraries listed in DT_NEEDED the same way ld.so does
+type resolver struct {
+	root    string
+	ldConf  []string
+	classes map[string]elf.Class
+}
+
+func newResolver(root string) *resolver {
+	return &resolver{
+		root:    root,
+		ldConf:  parseLdConf(filepath.Join(root, "etc/ld.so.conf"), root, map[string]bool{}),
+		classes: map[string]elf.Class{},
+	}
+}
+
+// missing returns the needed libraries of an ELF file that can't be resolved.
+// owned holds the base names of every file in the owning package.
+func (r *resolver) missing(path string, owned map[string]bool) ([]string, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Only dynamically linked objects have anything to resolve
+	if file.Type != elf.ET_EXEC && file.Type != elf.ET_DYN {
+		return nil, nil
+	}
+	needed, err := file.ImportedLibraries()
+	if err != nil || len(needed) == 0 {
+		return nil, nil
+	}
+
+	// RUNPATH takes precedence and disables RPATH
+	var dirs []string
+	runpath, _ := file.DynString(elf.DT_RUNPATH)
+	if len(runpath) == 0 {
+		runpath, _ = file.DynString(elf.DT_RPATH)
+	}
+	origin := filepath.Dir(path)
+	for _, entry := range runpath {
+		for _, dir := range strings.Split(entry, ":") {
+			if len(dir) > 0 {
+				dirs = append(dirs, expandOrigin(dir, origin, r.root))
+			}
+		}
+	}
+	dirs = append(dirs, r.ldConf...)
+	if file.Class == elf.ELFCLASS32 {
+		dirs = append(dirs, prefix(r.root, libDirs32)...)
+	}
+	dirs = append(dirs, prefix(r.root, libDirs64)...)
+
+	out := []string{}
+	for _, lib := range needed {
+		// Libraries shipped with the package are usually found via a wrapper
+		if owned[filepath.Base(lib)] {
+			continue
+		}
+		if !r.resolve(lib, dirs, file.Class) {
+			out = append(out, lib)
+		}
+	}
+	return out, nil
+}
+
+// resolve checks if lib exists in any of dirs with the same ELF class
+func (r *resolver) resolve(lib string, dirs []string, class elf.Class) bool {
+	if strings.Contains(lib, "/") {
+		return r.class(filepath.Join(r.root, lib)) == class
+	}
+	for _, dir := range dirs {
+		if r.class(filepath.Join(dir, lib)) == class {
+			return true
+		}
+	}
+	return false
+}
+
+// class returns the ELF class of path, or ELFCLASSNONE if it isn't a readable ELF file
+func (r *resolver) class(path string) elf.Class {
+	if class, ok := r.classes[path]; ok {
+		return class
+	}
+	class := elf.ELFCLASSNONE
+	if file, err := elf.Open(path); err == nil {
+		class = file.Class
+		file.Close()
+	}
+	r.classes[path] = class
+	return class
+}
+
+// parseLdConf reads the directories of an ld.so.conf file, following includes
+func parseLdConf(path, root string, seen map[string]bool) []string {
+	if seen[path] {
+		return nil
+	}
+	seen[path] = true
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	dirs := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(line, "include") {
+			for _, pattern := range strings.Fields(line)[1:] {
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(filepath.Dir(path), pattern)
+				} else {
+					pattern = filepath.Join(root, pattern)
+				}
+				matches, _ := filepath.Glob(pattern)
+				for _, match := range matches {
+					dirs = append(dirs, parseLdConf(match, root, seen)...)
+				}
+			}
+			continue
+		}
+		dirs = append(dirs, filepath.Join(root, line))
+	}
+	return dirs
+}
+
+// expandOrigin replaces $ORIGIN in an RPATH entry with the object's directory
+func expandOrigin(dir, origin, root string) string {
+	if !strings.Contains(dir, "ORIGIN") {
+		return filepath.Join(root, dir)
+	}
+	dir = strings.ReplaceAll(dir, "${ORIGIN}", origin)
+	return strings.ReplaceAll(dir, "$ORIGIN", origin)
+}
+
+func prefix(root string, dirs []string) []string {
+	out := make([]string, len(dirs))
+	for i, dir := range dirs {
+		out[i] = filepath.Join(root, dir)
+	}
+	return out
+}
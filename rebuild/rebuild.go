@@ -0,0 +1,194 @@
+package rebuild
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jguer/go-alpm/v2"
+	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/output"
+	"github.com/ericm/yup/sync"
+)
+
+// Broken represents a foreign package that needs to be rebuilt
+type Broken struct {
+	Name    string
+	Version string
+	Reasons []string
+}
+
+// Check finds AUR packages that need a rebuild and offers to rebuild them
+func Check() error {
+	output.Printf("Checking for AUR packages that need a rebuild...")
+	broken, err := Scan()
+	if err != nil {
+		return err
+	}
+	if len(broken) == 0 {
+		output.Printf("Found no AUR packages to rebuild")
+		return nil
+	}
+
+	output.Printf("Found %d AUR package(s) that need a rebuild:", len(broken))
+	for i, pack := range broken {
+		fmt.Printf("    %-3d \033[1m%s\033[0m %s (\033[91m%s\033[0m)\n", len(broken)-i, pack.Name, pack.Version, strings.Join(pack.Reasons, ", "))
+	}
+
+	names := make([]string, len(broken))
+	for i, pack := range broken {
+		names[i] = pack.Name
+	}
+
+	scanner := bufio.NewReader(os.Stdin)
+	output.PrintIn("Packages not to rebuild? (eg: 1 2 3, 1-3 or ^4)")
+	not, _ := scanner.ReadString('\n')
+	sync.ParseNumbersStr(not, &names)
+	if len(names) == 0 {
+		return nil
+	}
+
+	return sync.Rebuild(names)
+}
+
+// CheckUpgraded runs Check after a system upgrade, unless none of the upgraded packages
+// ship a shared library or are a runtime of the rebuild rules, so nothing can have broken
+func CheckUpgraded(upgraded []string) error {
+	conf := config.GetConfig()
+	handle, err := alpm.Initialize(conf.Pacman.RootDir, conf.Pacman.DBPath)
+	if err != nil {
+		return err
+	}
+	db, err := handle.LocalDB()
+	if err != nil {
+		handle.Release()
+		return err
+	}
+	runtimes := map[string]bool{}
+	for _, rule := range conf.UserFile.RebuildRules {
+		runtimes[rule.Runtime] = true
+	}
+	changed := changesLibraries(upgraded, runtimes, func(name string) []alpm.File {
+		if pkg := db.Pkg(name); pkg != nil {
+			return pkg.Files()
+		}
+		return nil
+	})
+	handle.Release()
+
+	if !changed {
+		return nil
+	}
+	return Check()
+}
+
+// changesLibraries checks if any of the upgraded packages is a runtime or has a shared library in its files
+func changesLibraries(upgraded []string, runtimes map[string]bool, files func(name string) []alpm.File) bool {
+	for _, name := range upgraded {
+		if runtimes[name] {
+			return true
+		}
+		for _, file := range files(name) {
+			base := filepath.Base(file.Name)
+			if strings.HasSuffix(base, ".so") || strings.Contains(base, ".so.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Scan checks every foreign package for unresolved libraries and
+// for files installed for an older version of a language runtime
+func Scan() ([]Broken, error) {
+	conf := config.GetConfig()
+	handle, err := alpm.Initialize(conf.Pacman.RootDir, conf.Pacman.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Release()
+	db, err := handle.LocalDB()
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := foreign()
+	if err != nil {
+		return nil, err
+	}
+
+	res := newResolver(conf.Pacman.RootDir)
+	rts := runtimes(db, conf.UserFile.RebuildRules)
+	out := []Broken{}
+	for _, name := range names {
+		pkg := db.Pkg(name)
+		if pkg == nil {
+			continue
+		}
+		files := pkg.Files()
+		reasons := append(brokenLibs(res, files), staleRuntimes(rts, files)...)
+		if len(reasons) > 0 {
+			out = append(out, Broken{Name: name, Version: pkg.Version(), Reasons: reasons})
+		}
+	}
+	return out, nil
+}
+
+// foreign returns the names of packages not found in the sync databases
+func foreign() ([]string, error) {
+	out, err := exec.Command("pacman", "-Qmq").Output()
+	if err != nil {
+		// pacman exits with 1 when there are no foreign packages
+		if _, ok := err.(*exec.ExitError); ok {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	names := []string{}
+	for _, name := range strings.Split(string(out), "\n") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// brokenLibs returns the unresolved libraries of every ELF file in files
+func brokenLibs(res *resolver, files []alpm.File) []string {
+	owned := map[string]bool{}
+	for _, file := range files {
+		owned[filepath.Base(file.Name)] = true
+	}
+
+	seen := map[string]bool{}
+	libs := []string{}
+	for _, file := range files {
+		path := filepath.Join(res.root, file.Name)
+		if !isObject(path) {
+			continue
+		}
+		missing, err := res.missing(path, owned)
+		if err != nil {
+			continue
+		}
+		for _, lib := range missing {
+			if !seen[lib] {
+				seen[lib] = true
+				libs = append(libs, lib)
+			}
+		}
+	}
+	return libs
+}
+
+// isObject filters out files that can't be executables or shared libraries
+func isObject(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() < 64 {
+		return false
+	}
+	return info.Mode()&0111 != 0 || strings.Contains(filepath.Base(path), ".so")
+}
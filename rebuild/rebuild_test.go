@@ -0,0 +1,121 @@
+package rebuild
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/Jguer/go-alpm/v2"
+	"github.com/ericm/yup/config"
+)
+
+func TestParseLdConf(t *testing.T) {
+	root, err := ioutil.TempDir("", "yup-rebuild")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	os.MkdirAll(filepath.Join(root, "etc/ld.so.conf.d"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "etc/ld.so.conf"), []byte("# comment\ninclude ld.so.conf.d/*.conf\n/usr/local/lib\n"), 0644)
+	ioutil.WriteFile(filepath.Join(root, "etc/ld.so.conf.d/cuda.conf"), []byte("/opt/cuda/lib64\n"), 0644)
+
+	dirs := parseLdConf(filepath.Join(root, "etc/ld.so.conf"), root, map[string]bool{})
+	want := []string{filepath.Join(root, "opt/cuda/lib64"), filepath.Join(root, "usr/local/lib")}
+	if !reflect.DeepEqual(dirs, want) {
+		t.Errorf("got %v, want %v", dirs, want)
+	}
+}
+
+// testdata/needy.elf is a tiny x86-64 library built with
+// gcc -shared -fPIC -nostdlib -s -o needy.elf needy.c libfound.so.1 libgone.so.2 libowned.so.3
+func TestMissing(t *testing.T) {
+	root, err := ioutil.TempDir("", "yup-rebuild")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	// Any ELF file of the same class stands in for libfound.so.1
+	lib, err := ioutil.ReadFile("testdata/needy.elf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(filepath.Join(root, "usr/lib"), 0755)
+	ioutil.WriteFile(filepath.Join(root, "usr/lib/libfound.so.1"), lib, 0755)
+
+	r := newResolver(root)
+	got, err := r.missing("testdata/needy.elf", map[string]bool{"libowned.so.3": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"libgone.so.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Files that aren't there are errors
+	if _, err := r.missing(filepath.Join(root, "etc"), nil); err == nil {
+		t.Error("no error for a missing file")
+	}
+}
+
+func TestExpandOrigin(t *testing.T) {
+	if dir := expandOrigin("$ORIGIN/../lib", "/opt/app/bin", "/"); dir != "/opt/app/bin/../lib" {
+		t.Errorf("got %s", dir)
+	}
+	if dir := expandOrigin("${ORIGIN}", "/opt/app", "/"); dir != "/opt/app" {
+		t.Errorf("got %s", dir)
+	}
+	if dir := expandOrigin("/usr/lib/foo", "/opt/app", "/root"); dir != "/root/usr/lib/foo" {
+		t.Errorf("got %s", dir)
+	}
+}
+
+func TestStaleRuntimes(t *testing.T) {
+	rts := []runtime{}
+	for _, rule := range config.DefaultRebuildRules() {
+		rts = append(rts, runtime{name: rule.Runtime, path: regexp.MustCompile(rule.Path)})
+	}
+	rts[0].current, rts[1].current, rts[2].current = "3.12", "5.38", "3.2"
+
+	files := []alpm.File{
+		{Name: "usr/lib/python3.11/site-packages/foo/__init__.py"},
+		{Name: "usr/lib/python3.11/site-packages/foo/bar.py"},
+		{Name: "usr/lib/python2.7/site-packages/old.py"},
+		{Name: "usr/lib/perl5/5.38/vendor_perl/Foo.pm"},
+		{Name: "usr/lib/ruby/gems/3.0.0/gems/foo/lib/foo.rb"},
+	}
+	got := staleRuntimes(rts, files)
+	want := []string{"python 3.11", "ruby 3.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestChangesLibraries(t *testing.T) {
+	files := map[string][]alpm.File{
+		"icu":    {{Name: "usr/lib/libicuuc.so.74.1"}, {Name: "usr/lib/libicuuc.so"}},
+		"vim":    {{Name: "usr/bin/vim"}, {Name: "usr/share/vim/vim91/syntax/go.vim"}},
+		"python": {{Name: "usr/bin/python3"}},
+	}
+	filesOf := func(name string) []alpm.File { return files[name] }
+	runtimes := map[string]bool{"python": true}
+
+	tests := []struct {
+		upgraded []string
+		want     bool
+	}{
+		{[]string{"vim"}, false},
+		{[]string{"vim", "icu"}, true},
+		{[]string{"python"}, true},
+		{nil, false},
+	}
+	for _, test := range tests {
+		if got := changesLibraries(test.upgraded, runtimes, filesOf); got != test.want {
+			t.Errorf("%v: got %v, want %v", test.upgraded, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package rebuild
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Jguer/go-alpm/v2"
+	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/output"
+)
+
+// runtime is a compiled config.RebuildRule with the installed runtime version
+type runtime struct {
+	name    string
+	path    *regexp.Regexp
+	current string
+}
+
+// runtimes compiles the configured rules against the installed runtimes.
+// Rules for runtimes that aren't installed are skipped
+func runtimes(db alpm.IDB, rules []config.RebuildRule) []runtime {
+	out := []runtime{}
+	for _, rule := range rules {
+		path, err := regexp.Compile(rule.Path)
+		if err != nil {
+			output.PrintErr("Invalid path in rebuild rule for %s: %s", rule.Runtime, err)
+			continue
+		}
+		version, err := regexp.Compile(rule.Version)
+		if err != nil {
+			output.PrintErr("Invalid version in rebuild rule for %s: %s", rule.Runtime, err)
+			continue
+		}
+
+		pkg := db.Pkg(rule.Runtime)
+		if pkg == nil {
+			continue
+		}
+		if match := version.FindStringSubmatch(pkg.Version()); len(match) > 1 {
+			out = append(out, runtime{name: rule.Runtime, path: path, current: match[1]})
+		}
+	}
+	return out
+}
+
+// staleRuntimes returns the runtimes that files were installed for but that have since changed version
+func staleRuntimes(rts []runtime, files []alpm.File) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, file := range files {
+		for _, rt := range rts {
+			match := rt.path.FindStringSubmatch(file.Name)
+			if len(match) < 2 || match[1] == rt.current {
+				continue
+			}
+			reason := fmt.Sprintf("%s %s", rt.name, match[1])
+			if !seen[reason] {
+				seen[reason] = true
+				out = append(out, reason)
+			}
+		}
+	}
+	return out
+}
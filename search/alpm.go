@@ -0,0 +1,203 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/Jguer/go-alpm/v2"
+	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/output"
+)
+
+// pacman's SigLevel when pacman.conf doesn't set one
+const defaultSigLevel = alpm.SigPackage | alpm.SigPackageOptional | alpm.SigDatabase | alpm.SigDatabaseOptional
+
+// sigLevel applies pacman.conf SigLevel options on top of level
+func sigLevel(level alpm.SigLevel, opts []string) alpm.SigLevel {
+	for _, opt := range opts {
+		pkg, db := true, true
+		if strings.HasPrefix(opt, "Package") {
+			db, opt = false, strings.TrimPrefix(opt, "Package")
+		} else if strings.HasPrefix(opt, "Database") {
+			pkg, opt = false, strings.TrimPrefix(opt, "Database")
+		}
+
+		var set, unset alpm.SigLevel
+		switch opt {
+		case "Never":
+			unset = alpm.SigPackage | alpm.SigDatabase
+		case "Optional":
+			set = alpm.SigPackage | alpm.SigPackageOptional | alpm.SigDatabase | alpm.SigDatabaseOptional
+		case "Required":
+			set = alpm.SigPackage | alpm.SigDatabase
+			unset = alpm.SigPackageOptional | alpm.SigDatabaseOptional
+		case "TrustedOnly":
+			unset = alpm.SigPackageMarginalOk | alpm.SigPackageUnknownOk | alpm.SigDatabaseMarginalOk | alpm.SigDatabaseUnknownOk
+		case "TrustAll":
+			set = alpm.SigPackageMarginalOk | alpm.SigPackageUnknownOk | alpm.SigDatabaseMarginalOk | alpm.SigDatabaseUnknownOk
+		}
+
+		// Package bits are below SigDatabase
+		mask := alpm.SigLevel(0)
+		if pkg {
+			mask |= alpm.SigDatabase - 1
+		}
+		if db {
+			mask |= alpm.SigUseDefault - alpm.SigDatabase
+		}
+		level = (level | set&mask) &^ (unset & mask)
+	}
+	return level
+}
+
+// usage converts the Usage of a repo, which defaults to All
+func usage(opts []string) alpm.Usage {
+	if len(opts) == 0 {
+		return alpm.UsageAll
+	}
+	var u alpm.Usage
+	for _, opt := range opts {
+		switch opt {
+		case "Sync":
+			u |= alpm.UsageSync
+		case "Search":
+			u |= alpm.UsageSearch
+		case "Install":
+			u |= alpm.UsageInstall
+		case "Upgrade":
+			u |= alpm.UsageUpgrade
+		case "All":
+			u |= alpm.UsageAll
+		}
+	}
+	return u
+}
+
+// syncSearch searches the sync databases in process, skipping those whose Usage leaves out Search.
+// Packages are matched by name and description, or by q.By.
+// Their dependencies are left for fillInfo
+func syncSearch(q Query) ([]output.Package, error) {
+	dbs, err := handle.SyncDBs()
+	if err != nil {
+		return nil, err
+	}
+	local, err := handle.LocalDB()
+	if err != nil {
+		return nil, err
+	}
+
+	skip := map[string]bool{}
+	for _, repo := range config.GetConfig().Pacman.Repos {
+		if usage(repo.Usage)&alpm.UsageSearch == 0 {
+			skip[repo.Name] = true
+		}
+	}
+
+	text := q.Text()
+	packs := []output.Package{}
+	for _, db := range dbs.Slice() {
+		if skip[db.Name()] {
+			continue
+		}
+		for _, pkg := range db.PkgCache().Slice() {
+			if !q.byNameDesc() && !matchField(pkg, q.By, text) {
+				continue
+			}
+			pack := lightPackage(db.Name(), pkg, local)
+			if q.Match(pack) {
+				packs = append(packs, pack)
+			}
+		}
+	}
+	return packs, nil
+}
+
+// syncPackage converts a sync package, filling in install info and dependencies from the local db
+func syncPackage(repo string, pkg alpm.IPackage, local alpm.IDB) output.Package {
+	pack := lightPackage(repo, pkg, local)
+	fillDepends(&pack, pkg, local)
+	return pack
+}
+
+// lightPackage converts a sync package without its dependencies, which are slow to check
+func lightPackage(repo string, pkg alpm.IPackage, local alpm.IDB) output.Package {
+	pack := output.Package{
+		Name:         pkg.Name(),
+		Repo:         repo,
+		Version:      pkg.Version(),
+		Description:  pkg.Description(),
+		Size:         pkg.ISize(),
+		DownloadSize: ToString(pkg.Size()),
+		Upstream:     pkg.URL(),
+		Maintainer:   pkg.Packager(),
+		Groups:       pkg.Groups().Slice(),
+		License:      pkg.Licenses().Slice(),
+		Provides:     depStrings(pkg.Provides()),
+		Conflicts:    depStrings(pkg.Conflicts()),
+		Replaces:     depStrings(pkg.Replaces()),
+	}
+
+	if in := local.Pkg(pkg.Name()); in != nil {
+		pack.Installed = true
+		pack.InstalledVersion = in.Version()
+		pack.InstalledSize = ToString(in.ISize())
+		pack.InstalledSizeInt = int(in.ISize())
+	}
+	return pack
+}
+
+// fillDepends fills in the dependencies of a sync package and whether they're installed
+func fillDepends(pack *output.Package, pkg alpm.IPackage, local alpm.IDB) {
+	installed := local.PkgCache()
+	pack.Depends = dependencies(pkg.Depends(), installed)
+	pack.OptDepends = dependencies(pkg.OptionalDepends(), installed)
+	pack.MakeDepends = dependencies(pkg.MakeDepends(), installed)
+	pack.CheckDepends = dependencies(pkg.CheckDepends(), installed)
+}
+
+func depStrings(deps alpm.DependList) []string {
+	out := []string{}
+	for _, dep := range deps.Slice() {
+		out = append(out, dep.String())
+	}
+	return out
+}
+
+// dependencies converts deps, checking if an installed package satisfies each
+func dependencies(deps alpm.DependList, installed alpm.IPackageList) []output.Dependency {
+	out := []output.Dependency{}
+	for _, dep := range deps.Slice() {
+		pkg, err := installed.FindSatisfier(dep.String())
+		out = append(out, output.Dependency{Name: dep.String(), Installed: err == nil && pkg != nil})
+	}
+	return out
+}
+
+// matchField checks a single field of a package like the AUR RPC does
+func matchField(pkg alpm.IPackage, by, text string) bool {
+	var deps alpm.DependList
+	switch by {
+	case "name":
+		return strings.Contains(pkg.Name(), text)
+	case "maintainer":
+		return strings.Contains(strings.ToLower(pkg.Packager()), text)
+	case "depends":
+		deps = pkg.Depends()
+	case "makedepends":
+		deps = pkg.MakeDepends()
+	case "optdepends":
+		deps = pkg.OptionalDepends()
+	case "checkdepends":
+		deps = pkg.CheckDepends()
+	case "provides":
+		if pkg.Name() == text {
+			return true
+		}
+		deps = pkg.Provides()
+	}
+	for _, dep := range deps.Slice() {
+		if dep.Name == text {
+			return true
+		}
+	}
+	return false
+}
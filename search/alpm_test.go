@@ -0,0 +1,27 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/Jguer/go-alpm/v2"
+)
+
+func TestSigLevel(t *testing.T) {
+	tests := []struct {
+		base alpm.SigLevel
+		opts []string
+		want alpm.SigLevel
+	}{
+		{defaultSigLevel, []string{"Required", "DatabaseOptional"}, alpm.SigPackage | alpm.SigDatabase | alpm.SigDatabaseOptional},
+		{defaultSigLevel, []string{"Never"}, alpm.SigPackageOptional | alpm.SigDatabaseOptional},
+		{alpm.SigPackage | alpm.SigDatabase, []string{"PackageOptional", "TrustAll"},
+			alpm.SigPackage | alpm.SigPackageOptional | alpm.SigPackageMarginalOk | alpm.SigPackageUnknownOk |
+				alpm.SigDatabase | alpm.SigDatabaseMarginalOk | alpm.SigDatabaseUnknownOk},
+		{alpm.SigPackage | alpm.SigPackageUnknownOk, []string{"PackageTrustedOnly", "DatabaseNever"}, alpm.SigPackage},
+	}
+	for _, test := range tests {
+		if got := sigLevel(test.base, test.opts); got != test.want {
+			t.Errorf("%v: got %b, want %b", test.opts, got, test.want)
+		}
+	}
+}
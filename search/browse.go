@@ -0,0 +1,242 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Jguer/go-alpm/v2"
+	"github.com/ericm/yup/output"
+	"github.com/ericm/yup/sync"
+	"github.com/ericm/yup/tui"
+)
+
+// installed is a package of the local db, as listed by Browse
+type installed struct {
+	pack       output.Package
+	explicit   bool
+	requiredBy int
+	orphan     bool
+}
+
+// Columns the browser sorts by, in the order the sort key cycles through
+var browseColumns = []string{"name", "repo", "reason", "size", "required-by", "orphan"}
+
+// Actions of the browser besides install, which reinstalls, and remove
+var browseActions = []tui.Action{
+	{Name: "sort", Label: "Sort"},
+	{Name: "asdeps", Label: "As deps"},
+	{Name: "asexplicit", Label: "Explicit"},
+	{Name: "files", Label: "Files"},
+}
+
+// Browse lists the installed packages with ncurses, to sort, filter and act on them
+func Browse() error {
+	column := 0
+	list := &tui.List{Remove: true, Cascade: removalPlan, Extra: browseActions}
+	// Read once and sorted again in place, until an action changes what's installed
+	var packs []installed
+	for {
+		if packs == nil {
+			var err error
+			if packs, err = localPackages(); err != nil {
+				return err
+			}
+		}
+		sortInstalled(packs, browseColumns[column])
+
+		// Keep the checked packages across runs, as their numbers change
+		checked := map[string]bool{}
+		for i, pack := range list.Packages {
+			if list.Checked[len(list.Packages)-i] {
+				checked[pack.Name] = true
+			}
+		}
+		list.Title = fmt.Sprintf("Sorted by %s. Or type packages", browseColumns[column])
+		list.Packages, list.Info, list.Checked = nil, nil, map[int]bool{}
+		for i, pack := range packs {
+			list.Packages = append(list.Packages, pack.pack)
+			list.Info = append(list.Info, pack.info())
+			list.Checked[len(packs)-i] = checked[pack.pack.Name]
+		}
+
+		selection, action := list.Run()
+		names := []string{}
+		for _, pack := range selection {
+			names = append(names, pack.Name)
+		}
+		switch action {
+		case "quit":
+			return nil
+		case "sort":
+			column = (column + 1) % len(browseColumns)
+			continue
+		}
+		if len(names) == 0 {
+			continue
+		}
+
+		if err := browseAction(action, names, selection); err != nil {
+			output.PrintErr("%s", err)
+		}
+		// The list shows what changed once enter is pressed
+		list.Checked = map[int]bool{}
+		output.PrintIn("Press enter to go back to the list")
+		fmt.Scanln()
+		if action == "files" {
+			continue
+		}
+		// Read what pacman changed
+		if err := reload(); err != nil {
+			return err
+		}
+		packs = nil
+	}
+}
+
+// browseAction runs an action of the browser on the selected packages
+func browseAction(action string, names []string, selection []output.Package) error {
+	switch action {
+	case "remove":
+		// What requires them was confirmed in the list
+		return removeCascade(names)
+	case "asdeps", "asexplicit":
+		cmd := exec.Command("sudo", append([]string{"pacman", "-D", "--" + action}, names...)...)
+		output.SetStd(cmd)
+		return cmd.Run()
+	case "files":
+		// Shown in a pager, as there are usually too many to scroll back through
+		pager := os.Getenv("PAGER")
+		if len(pager) == 0 {
+			pager = "less"
+		}
+		cmd := exec.Command("sh", "-c", `pacman -Ql "$@" | `+pager, "sh")
+		cmd.Args = append(cmd.Args, names...)
+		output.SetStd(cmd)
+		return cmd.Run()
+	case "install":
+		// Reinstall, AUR packages by building them again
+		repo, aur := []string{}, []string{}
+		for _, pack := range selection {
+			if pack.Repo == "foreign" {
+				aur = append(aur, pack.Name)
+			} else {
+				repo = append(repo, pack.Name)
+			}
+		}
+		if len(repo) > 0 {
+			output.Printf("Reinstalling \033[1m\033[32m%s\033[39m\033[0m with \033[1mpacman\033[0m", strings.Join(repo, " "))
+			cmd := exec.Command("sudo", append([]string{"pacman", "-S"}, repo...)...)
+			output.SetStd(cmd)
+			if err := cmd.Run(); err != nil {
+				return err
+			}
+		}
+		if len(aur) > 0 {
+			return sync.Rebuild(aur)
+		}
+	}
+	return nil
+}
+
+// localPackages reads the installed packages and where they come from
+func localPackages() ([]installed, error) {
+	local, err := handle.LocalDB()
+	if err != nil {
+		return nil, err
+	}
+	dbs, err := handle.SyncDBs()
+	if err != nil {
+		return nil, err
+	}
+
+	packs := []installed{}
+	cache := local.PkgCache()
+	for _, pkg := range cache.Slice() {
+		pack := installed{
+			pack: output.Package{
+				Name:             pkg.Name(),
+				Repo:             repoOf(dbs, pkg.Name()),
+				Version:          pkg.Version(),
+				Description:      pkg.Description(),
+				Upstream:         pkg.URL(),
+				InstalledVersion: pkg.Version(),
+				InstalledSize:    ToString(pkg.ISize()),
+				InstalledSizeInt: int(pkg.ISize()),
+				Groups:           pkg.Groups().Slice(),
+				License:          pkg.Licenses().Slice(),
+				Provides:         depStrings(pkg.Provides()),
+				Conflicts:        depStrings(pkg.Conflicts()),
+				Replaces:         depStrings(pkg.Replaces()),
+				Depends:          dependencies(pkg.Depends(), cache),
+				OptDepends:       dependencies(pkg.OptionalDepends(), cache),
+			},
+			explicit:   pkg.Reason() == alpm.PkgReasonExplicit,
+			requiredBy: len(pkg.ComputeRequiredBy()),
+		}
+		// Nothing needs it, even optionally
+		pack.orphan = !pack.explicit && pack.requiredBy == 0 && len(pkg.ComputeOptionalFor()) == 0
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
+
+// repoOf returns the first sync db with the package, or foreign
+func repoOf(dbs alpm.IDBList, name string) string {
+	for _, db := range dbs.Slice() {
+		if db.Pkg(name) != nil {
+			return db.Name()
+		}
+	}
+	return "foreign"
+}
+
+// info is the columns shown after the version
+func (pack installed) info() string {
+	reason := "dependency"
+	if pack.explicit {
+		reason = "explicit"
+	}
+	info := fmt.Sprintf("%s, %s, required by %d", reason, pack.pack.InstalledSize, pack.requiredBy)
+	if pack.orphan {
+		info += ", orphan"
+	}
+	return info
+}
+
+// sortInstalled sorts by a browser column, with the largest last, nearest the prompt
+func sortInstalled(packs []installed, column string) {
+	key := func(pack installed) string {
+		switch column {
+		case "repo":
+			return pack.pack.Repo
+		case "reason":
+			return strconv.FormatBool(!pack.explicit)
+		case "orphan":
+			return strconv.FormatBool(pack.orphan)
+		}
+		return ""
+	}
+	sort.SliceStable(packs, func(i, j int) bool {
+		a, b := packs[i], packs[j]
+		switch column {
+		case "size":
+			if a.pack.InstalledSizeInt != b.pack.InstalledSizeInt {
+				return a.pack.InstalledSizeInt < b.pack.InstalledSizeInt
+			}
+		case "required-by":
+			if a.requiredBy != b.requiredBy {
+				return a.requiredBy < b.requiredBy
+			}
+		default:
+			if ka, kb := key(a), key(b); ka != kb {
+				return ka < kb
+			}
+		}
+		// Name is the tiebreak, A to Z down the list
+		return a.pack.Name < b.pack.Name
+	})
+}
@@ -0,0 +1,31 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/ericm/yup/output"
+)
+
+func TestSortInstalled(t *testing.T) {
+	packs := []installed{
+		{pack: output.Package{Name: "zlib", Repo: "core", InstalledSizeInt: 300}, requiredBy: 40},
+		{pack: output.Package{Name: "yup", Repo: "foreign", InstalledSizeInt: 900}, explicit: true},
+		{pack: output.Package{Name: "libfoo", Repo: "extra", InstalledSizeInt: 300}, orphan: true},
+	}
+	tests := map[string][]string{
+		"name":        {"libfoo", "yup", "zlib"},
+		"repo":        {"zlib", "libfoo", "yup"},
+		"reason":      {"yup", "libfoo", "zlib"},
+		"size":        {"libfoo", "zlib", "yup"},
+		"required-by": {"libfoo", "yup", "zlib"},
+		"orphan":      {"yup", "zlib", "libfoo"},
+	}
+	for column, want := range tests {
+		sortInstalled(packs, column)
+		for i, pack := range packs {
+			if pack.pack.Name != want[i] {
+				t.Errorf("%s: got %s at %d, want %s", column, pack.pack.Name, i, want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fields packages can be searched by
+var byFields = []string{"name-desc", "name", "maintainer", "depends", "makedepends", "optdepends", "checkdepends", "provides"}
+
+// ValidBy checks if field can be passed to --by or by:
+func ValidBy(field string) error {
+	for _, f := range byFields {
+		if f == field {
+			return nil
+		}
+	}
+	return fmt.Errorf("Can't search by %s, use one of: %s", field, strings.Join(byFields, ", "))
+}
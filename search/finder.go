@@ -0,0 +1,79 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/ericm/yup/output"
+)
+
+// pickFinder lets the user pick packages with a fuzzy finder such as fzf or sk,
+// run as the command and arguments in finder.
+// It returns no packages if they closed it without picking any
+func pickFinder(finder []string, packs []output.Package) ([]output.Package, error) {
+	// The preview calls back into yup for the package's info
+	self, err := os.Executable()
+	if err != nil {
+		self = "yup"
+	}
+	preview := fmt.Sprintf("%s -Si {3} 2>/dev/null || pacman -Sg {3}", shellQuote(self))
+
+	args := []string{}
+	for _, arg := range finder[1:] {
+		args = append(args, strings.Replace(arg, "{preview}", preview, -1))
+	}
+	cmd := exec.Command(finder[0], args...)
+	cmd.Stdin = strings.NewReader(finderLines(packs))
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && (exitErr.ExitCode() == 1 || exitErr.ExitCode() == 130) {
+		// Nothing matched, or it was closed
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't run %s: %s", finder[0], err)
+	}
+	return finderPicked(string(out), packs), nil
+}
+
+// finderLines lists packages for a fuzzy finder, best match first.
+// Each line is the package's number, repo, name and what's shown, separated by tabs
+func finderLines(packs []output.Package) string {
+	theme := output.CurrentTheme()
+	lines := &strings.Builder{}
+	for i := len(packs) - 1; i >= 0; i-- {
+		pack := packs[i]
+		installed := ""
+		if pack.Installed {
+			installed = fmt.Sprintf(" (\033[1m%sINSTALLED\033[0m)", output.ANSI(theme.Installed))
+		}
+		// Tabs and newlines would break the columns
+		desc := strings.Join(strings.Fields(pack.Description), " ")
+		fmt.Fprintf(lines, "%d\t%s\t%s\t%s\033[2m/\033[0m\033[1m%s\033[0m %s%s \033[2m- %s\033[0m\n",
+			len(packs)-i, pack.Repo, pack.Name, output.RepoColor(pack.Repo), pack.Name, pack.Version, installed, desc)
+	}
+	return lines.String()
+}
+
+// finderPicked returns the packages of the lines a fuzzy finder printed
+func finderPicked(out string, packs []output.Package) []output.Package {
+	picked := []output.Package{}
+	seen := map[int]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		num, err := strconv.Atoi(strings.SplitN(line, "\t", 2)[0])
+		if err != nil || num < 1 || num > len(packs) || seen[num] {
+			continue
+		}
+		seen[num] = true
+		picked = append(picked, packs[len(packs)-num])
+	}
+	return picked
+}
+
+// shellQuote quotes s for sh, which runs the preview command
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
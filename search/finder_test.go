@@ -0,0 +1,34 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ericm/yup/output"
+)
+
+func TestFinder(t *testing.T) {
+	packs := []output.Package{
+		{Name: "yup-git", Repo: "aur", Version: "r1", Description: "Development\tversion"},
+		{Name: "yup", Repo: "aur", Version: "1.1.8", Description: "Pacman wrapper"},
+	}
+	lines := strings.Split(strings.TrimSpace(finderLines(packs)), "\n")
+	// Best match first, with four columns
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "1\taur\tyup\t") || len(strings.Split(lines[1], "\t")) != 4 {
+		t.Fatalf("lines: got %q", lines)
+	}
+
+	picked := finderPicked(lines[1]+"\n"+lines[0]+"\n"+lines[0]+"\n", packs)
+	if len(picked) != 2 || picked[0].Name != "yup-git" || picked[1].Name != "yup" {
+		t.Errorf("picked: got %v", picked)
+	}
+	if picked := finderPicked("", packs); len(picked) != 0 {
+		t.Errorf("nothing picked: got %v", picked)
+	}
+
+	// Any command reading the lines and printing the picked ones will do
+	picked, err := pickFinder([]string{"head", "-n", "1"}, packs)
+	if err != nil || len(picked) != 1 || picked[0].Name != "yup" {
+		t.Errorf("custom command: got %v, %v", picked, err)
+	}
+}
@@ -0,0 +1,105 @@
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ericm/yup/output"
+	"github.com/ericm/yup/sync"
+	"github.com/ericm/yup/tui"
+)
+
+// GroupMembers returns the packages in a group from the sync databases
+func GroupMembers(group string) ([]output.Package, error) {
+	dbs, err := handle.SyncDBs()
+	if err != nil {
+		return nil, err
+	}
+	local, err := handle.LocalDB()
+	if err != nil {
+		return nil, err
+	}
+
+	members := []output.Package{}
+	for _, pkg := range dbs.FindGroupPkgs(group).Slice() {
+		members = append(members, syncPackage(pkg.DB().Name(), pkg, local))
+	}
+	// Sorted so the first member is shown at the top
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].Name > members[j].Name
+	})
+	return members, nil
+}
+
+// installGroup asks which members of a group to install, then installs them with one pacman call
+func installGroup(group string) error {
+	members, err := GroupMembers(group)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("Group %s has no packages", group)
+	}
+
+	names := []string{}
+	for _, pack := range selectMembers(group, members) {
+		names = append(names, pack.Name)
+	}
+	if len(names) == 0 {
+		output.Printf("Skipping group %s", group)
+		return nil
+	}
+	return sync.SyncRepo(names)
+}
+
+// selectMembers lets the user pick members of a group, with the installed ones already marked
+func selectMembers(group string, members []output.Package) []output.Package {
+	checked := map[int]bool{}
+	for i, pack := range members {
+		if pack.Installed {
+			checked[len(members)-i] = true
+		}
+	}
+
+	if tui.Enabled() {
+		list := &tui.List{
+			Title:     fmt.Sprintf("Or type members of %s to install", group),
+			Packages:  members,
+			Checked:   checked,
+			AllowNone: true,
+		}
+		if picked, action := list.Run(); action == "install" {
+			return picked
+		}
+		return nil
+	}
+
+	output.Printf("Group \033[1m%s\033[0m has %d members:", group, len(members))
+	for i, pack := range members {
+		installed := ""
+		if pack.Installed {
+			installed = " \033[1m\033[32m[Installed]\033[0m"
+		}
+		fmt.Print("\033[37m\033[1m")
+		fmt.Printf("%-5s", fmt.Sprintf("(%d)", len(members)-i))
+		fmt.Print("\033[0m")
+		fmt.Printf("\033[1m%s\033[0m %s (%s)%s\n", pack.Name, pack.Version, ToString(pack.Size), installed)
+	}
+
+	output.PrintIn("Members to install (eg: 1 2 3, 1-3 or ^4, default: installed ones)")
+	input, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if len(strings.TrimSpace(input)) > 0 {
+		return pickNumbers(input, members)
+	}
+
+	picked := []output.Package{}
+	for i, pack := range members {
+		if checked[len(members)-i] {
+			picked = append(picked, pack)
+		}
+	}
+	return picked
+}
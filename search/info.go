@@ -0,0 +1,139 @@
+package search
+
+import (
+	"strings"
+
+	"github.com/Jguer/go-alpm/v2"
+	"github.com/ericm/yup/index"
+	"github.com/ericm/yup/output"
+)
+
+// aurPackage converts an AUR package, filling in install info from the local db
+func aurPackage(pack index.Pkg, local alpm.IDB) output.Package {
+	newPack := output.Package{
+		Aur:            true,
+		Name:           pack.Name,
+		Repo:           "aur",
+		Description:    pack.Description,
+		Version:        pack.Version,
+		OutOfDate:      pack.OutOfDate,
+		Upstream:       pack.URL,
+		Votes:          pack.NumVotes,
+		Popularity:     pack.Popularity,
+		Maintainer:     pack.Maintainer,
+		Groups:         pack.Groups,
+		Provides:       pack.Provides,
+		Base:           pack.PackageBase,
+		CoMaintainers:  pack.CoMaintainers,
+		FirstSubmitted: pack.FirstSubmitted,
+		LastModified:   pack.LastModified,
+		License:        pack.License,
+		Keywords:       pack.Keywords,
+		Conflicts:      pack.Conflicts,
+		Replaces:       pack.Replaces,
+	}
+
+	// Check if its installed
+	if in := local.Pkg(pack.Name); in != nil {
+		newPack.Installed = true
+		newPack.InstalledVersion = in.Version()
+		newPack.InstalledSize = ToString(in.ISize())
+		newPack.InstalledSizeInt = int(in.ISize())
+		newPack.DownloadSize = ToString(in.ISize())
+	}
+	return newPack
+}
+
+// AurInfo returns every field of AUR packages, with the install status of their dependencies
+func AurInfo(names []string) ([]output.Package, error) {
+	local, err := handle.LocalDB()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := index.Info(names)
+	if err != nil {
+		return nil, err
+	}
+
+	installed := local.PkgCache()
+	satisfied := func(deps []string) []output.Dependency {
+		out := []output.Dependency{}
+		for _, dep := range deps {
+			// Optional deps are described after a colon
+			name := strings.TrimSpace(strings.SplitN(dep, ":", 2)[0])
+			pkg, err := installed.FindSatisfier(name)
+			out = append(out, output.Dependency{Name: dep, Installed: err == nil && pkg != nil})
+		}
+		return out
+	}
+
+	packs := []output.Package{}
+	for _, info := range infos {
+		pack := aurPackage(info, local)
+		pack.Depends = satisfied(info.Depends)
+		pack.MakeDepends = satisfied(info.MakeDepends)
+		pack.CheckDepends = satisfied(info.CheckDepends)
+		pack.OptDepends = satisfied(info.OptDepends)
+		packs = append(packs, pack)
+	}
+	return packs, nil
+}
+
+// RepoInfo returns the packages with the given names from the sync databases
+func RepoInfo(names []string) ([]output.Package, error) {
+	dbs, err := handle.SyncDBs()
+	if err != nil {
+		return nil, err
+	}
+	local, err := handle.LocalDB()
+	if err != nil {
+		return nil, err
+	}
+	packs := []output.Package{}
+	for _, name := range names {
+		// Like pacman, repo/name picks the repo
+		repo := ""
+		if spl := strings.SplitN(name, "/", 2); len(spl) == 2 {
+			repo, name = spl[0], spl[1]
+		}
+		for _, db := range dbs.Slice() {
+			if len(repo) > 0 && db.Name() != repo {
+				continue
+			}
+			if pkg := db.Pkg(name); pkg != nil {
+				packs = append(packs, syncPackage(db.Name(), pkg, local))
+				break
+			}
+		}
+	}
+	return packs, nil
+}
+
+// InRepos splits names into those found in the sync databases and the rest
+func InRepos(names []string) ([]string, []string, error) {
+	dbs, err := handle.SyncDBs()
+	if err != nil {
+		return nil, nil, err
+	}
+	repo, rest := []string{}, []string{}
+	for _, name := range names {
+		// repo/name targets are never AUR packages
+		if strings.Contains(name, "/") {
+			repo = append(repo, name)
+			continue
+		}
+		found := false
+		for _, db := range dbs.Slice() {
+			if db.Pkg(name) != nil {
+				found = true
+				break
+			}
+		}
+		if found {
+			repo = append(repo, name)
+		} else {
+			rest = append(rest, name)
+		}
+	}
+	return repo, rest, nil
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ericm/yup/output"
+)
+
+// PKGBUILDs shown in the preview pane, by package base
+var pkgbuilds = map[string]string{}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// fillInfo fills in the dependencies of a search result.
+// AUR results are replaced with their full info
+func fillInfo(pack *output.Package) {
+	if !pack.Aur {
+		fillRepoInfo(pack)
+		return
+	}
+	if len(pack.Base) > 0 {
+		return
+	}
+	if infos, err := AurInfo([]string{pack.Name}); err == nil && len(infos) > 0 {
+		infos[0].SortValue = pack.SortValue
+		*pack = infos[0]
+	}
+}
+
+// fillRepoInfo fills in the dependencies of a repo search result, left out by syncSearch
+func fillRepoInfo(pack *output.Package) {
+	if pack.Depends != nil {
+		return
+	}
+	dbs, err := handle.SyncDBs()
+	if err != nil {
+		return
+	}
+	local, err := handle.LocalDB()
+	if err != nil {
+		return
+	}
+	for _, db := range dbs.Slice() {
+		if db.Name() != pack.Repo {
+			continue
+		}
+		if pkg := db.Pkg(pack.Name); pkg != nil {
+			fillDepends(pack, pkg, local)
+		}
+	}
+}
+
+// fetchPkgbuild downloads the PKGBUILD of an AUR package base, once
+func fetchPkgbuild(base string) (string, error) {
+	if pkgbuild, ok := pkgbuilds[base]; ok {
+		return pkgbuild, nil
+	}
+	resp, err := client.Get("https://aur.archlinux.org/cgit/aur.git/plain/PKGBUILD?h=" + url.QueryEscape(base))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("PKGBUILD of %s: %s", base, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	pkgbuilds[base] = string(body)
+	return pkgbuilds[base], nil
+}
+
+// pkgbuild returns the PKGBUILD shown in the preview pane
+func pkgbuild(pack output.Package) (string, error) {
+	if !pack.Aur {
+		return "", fmt.Errorf("Only shown for AUR packages")
+	}
+	return fetchPkgbuild(pack.Base)
+}
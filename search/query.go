@@ -0,0 +1,196 @@
+package search
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ericm/yup/output"
+)
+
+// Query is a parsed search query.
+//
+// Plain words and "quoted phrases" match the name or description,
+// -word excludes and field:value terms filter on package fields
+type Query struct {
+	Words      []string
+	Names      []string
+	Exclude    []string
+	Repo       string
+	Installed  *bool
+	OutOfDate  *bool
+	Maintainer string
+	VotesOp    string
+	Votes      int
+	By         string
+}
+
+var votesRe = regexp.MustCompile(`^(>=|<=|>|<|=)?(\d+)$`)
+
+// ParseQuery parses the query syntax used by every search.
+// It fails if by: names a field that can't be searched by
+func ParseQuery(query string) (Query, error) {
+	q := Query{}
+	for _, tok := range tokenize(query) {
+		exclude := false
+		if strings.HasPrefix(tok, "-") && len(tok) > 1 {
+			exclude = true
+			tok = tok[1:]
+		}
+
+		field, value := "", tok
+		if i := strings.Index(tok, ":"); i > 0 && !exclude {
+			field, value = strings.ToLower(tok[:i]), strings.ToLower(tok[i+1:])
+		}
+
+		switch field {
+		case "repo":
+			q.Repo = value
+		case "installed":
+			q.Installed = parseBool(value)
+		case "outofdate":
+			q.OutOfDate = parseBool(value)
+		case "maintainer":
+			q.Maintainer = value
+		case "by":
+			if err := ValidBy(value); err != nil {
+				return q, err
+			}
+			q.By = value
+		case "name":
+			q.Names = append(q.Names, value)
+		case "votes":
+			if match := votesRe.FindStringSubmatch(value); match != nil {
+				q.VotesOp = match[1]
+				if q.VotesOp == "" {
+					q.VotesOp = ">="
+				}
+				q.Votes, _ = strconv.Atoi(match[2])
+			}
+		default:
+			if exclude {
+				q.Exclude = append(q.Exclude, strings.ToLower(tok))
+			} else {
+				q.Words = append(q.Words, strings.ToLower(tok))
+			}
+		}
+	}
+	return q, nil
+}
+
+// tokenize splits on spaces, keeping "quoted phrases" together
+func tokenize(query string) []string {
+	toks := []string{}
+	cur := ""
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ' ' && !quoted:
+			if len(cur) > 0 {
+				toks = append(toks, cur)
+			}
+			cur = ""
+		default:
+			cur += string(r)
+		}
+	}
+	if len(cur) > 0 {
+		toks = append(toks, cur)
+	}
+	return toks
+}
+
+func parseBool(value string) *bool {
+	var b bool
+	switch value {
+	case "yes", "y", "true", "1":
+		b = true
+	case "no", "n", "false", "0":
+		b = false
+	default:
+		return nil
+	}
+	return &b
+}
+
+// Terms returns the words sent to the remote searches
+func (q Query) Terms() []string {
+	return append(append([]string{}, q.Names...), q.Words...)
+}
+
+// Text returns the query without filters, used for sorting
+func (q Query) Text() string {
+	return strings.Join(q.Terms(), " ")
+}
+
+// Source checks if the repo filter allows searching the AUR or the repos
+func (q Query) Source(aur bool) bool {
+	if len(q.Repo) == 0 {
+		return true
+	}
+	return (q.Repo == "aur") == aur
+}
+
+// byNameDesc reports whether the words are searched in name and description
+func (q Query) byNameDesc() bool {
+	return len(q.By) == 0 || q.By == "name-desc"
+}
+
+// Match checks a package against every term in the query
+func (q Query) Match(pack output.Package) bool {
+	name := strings.ToLower(pack.Name)
+	desc := strings.ToLower(pack.Description)
+
+	for _, word := range q.Words {
+		switch {
+		case q.byNameDesc():
+			if !strings.Contains(name, word) && !strings.Contains(desc, word) {
+				return false
+			}
+		case q.By == "name":
+			if !strings.Contains(name, word) {
+				return false
+			}
+		}
+		// Other fields are only known to the search itself
+	}
+	for _, word := range q.Names {
+		if !strings.Contains(name, word) {
+			return false
+		}
+	}
+	for _, word := range q.Exclude {
+		if strings.Contains(name, word) || strings.Contains(desc, word) {
+			return false
+		}
+	}
+
+	if len(q.Repo) > 0 && q.Repo != strings.ToLower(pack.Repo) {
+		return false
+	}
+	if q.Installed != nil && *q.Installed != pack.Installed {
+		return false
+	}
+	if q.OutOfDate != nil && *q.OutOfDate != (pack.OutOfDate != 0) {
+		return false
+	}
+	if len(q.Maintainer) > 0 && q.Maintainer != strings.ToLower(pack.Maintainer) {
+		return false
+	}
+
+	switch q.VotesOp {
+	case ">":
+		return pack.Votes > q.Votes
+	case ">=":
+		return pack.Votes >= q.Votes
+	case "<":
+		return pack.Votes < q.Votes
+	case "<=":
+		return pack.Votes <= q.Votes
+	case "=":
+		return pack.Votes == q.Votes
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ericm/yup/output"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`repo:aur installed:no votes:>100 maintainer:Foo outofdate:no name:code -insiders "visual studio"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Repo != "aur" || q.Installed == nil || *q.Installed || q.OutOfDate == nil || *q.OutOfDate {
+		t.Errorf("filters not parsed: %+v", q)
+	}
+	if q.VotesOp != ">" || q.Votes != 100 || q.Maintainer != "foo" {
+		t.Errorf("votes or maintainer not parsed: %+v", q)
+	}
+	if !reflect.DeepEqual(q.Names, []string{"code"}) || !reflect.DeepEqual(q.Exclude, []string{"insiders"}) ||
+		!reflect.DeepEqual(q.Words, []string{"visual studio"}) {
+		t.Errorf("words not parsed: %+v", q)
+	}
+	if q.Text() != "code visual studio" {
+		t.Errorf("got text %q", q.Text())
+	}
+
+	if _, err := ParseQuery("by:votes vscode"); err == nil {
+		t.Error("by:votes: expected an error")
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	pack := output.Package{
+		Name:        "visual-studio-code-bin",
+		Description: "Visual Studio Code (vscode)",
+		Repo:        "aur",
+		Votes:       1200,
+		Maintainer:  "foo",
+	}
+	matches := map[string]bool{
+		"code":                        true,
+		`"studio code"`:               true,
+		"name:vscode":                 false,
+		"code -bin":                   false,
+		"repo:aur votes:>1000":        true,
+		"repo:core":                   false,
+		"votes:<=1000":                false,
+		"installed:yes":               false,
+		"maintainer:FOO outofdate:no": true,
+		"by:depends electron":         true,
+		"by:name studio":              true,
+		"by:name vscode":              false,
+	}
+	for query, want := range matches {
+		q, err := ParseQuery(query)
+		if err != nil {
+			t.Errorf("%s: %s", query, err)
+		}
+		if got := q.Match(pack); got != want {
+			t.Errorf("%s: got %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestValidBy(t *testing.T) {
+	if err := ValidBy("provides"); err != nil {
+		t.Error(err)
+	}
+	if err := ValidBy("votes"); err == nil {
+		t.Error("votes isn't a valid search field")
+	}
+}
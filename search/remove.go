@@ -0,0 +1,83 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/ericm/yup/output"
+	"github.com/ericm/yup/sync"
+	"github.com/ericm/yup/tui"
+)
+
+// reload reads the databases again, after pacman has changed them
+func reload() error {
+	if handle != nil {
+		handle.Release()
+	}
+	return Init()
+}
+
+// removalPlan returns the packages removing packs takes with it, confirmed in the list before removing them
+func removalPlan(packs []output.Package) ([]output.Package, error) {
+	return sync.RemovalPlan(packNames(packs))
+}
+
+// removeCascade removes the packages along with those requiring them
+func removeCascade(names []string) error {
+	plan, err := sync.RemovalPlan(names)
+	if err != nil {
+		return err
+	}
+	if len(plan) == 0 {
+		return fmt.Errorf("None of the selected packages are installed")
+	}
+	return sync.RemoveAll(packNames(plan))
+}
+
+// removeFromList removes the packages picked in a list, then shows what's still installed in it
+func removeFromList(list *tui.List, picked []output.Package) {
+	if err := removeCascade(packNames(picked)); err != nil {
+		output.PrintErr("%s", err)
+	}
+	output.PrintIn("Press enter to go back to the list")
+	fmt.Scanln()
+
+	if err := refreshInstalled(list.Packages); err != nil {
+		output.PrintErr("%s", err)
+	}
+	list.Checked = map[int]bool{}
+}
+
+// refreshInstalled updates whether packages are installed, and which version
+func refreshInstalled(packs []output.Package) error {
+	if err := reload(); err != nil {
+		return err
+	}
+	local, err := handle.LocalDB()
+	if err != nil {
+		return err
+	}
+	for i := range packs {
+		pack := &packs[i]
+		if pack.Repo == "group" {
+			continue
+		}
+		pkg := local.Pkg(pack.Name)
+		pack.Installed = pkg != nil
+		if pkg == nil {
+			pack.InstalledVersion, pack.InstalledSize, pack.InstalledSizeInt = "", "", 0
+			continue
+		}
+		pack.InstalledVersion = pkg.Version()
+		pack.InstalledSize = ToString(pkg.ISize())
+		pack.InstalledSizeInt = int(pkg.ISize())
+	}
+	return nil
+}
+
+func packNames(packs []output.Package) []string {
+	names := []string{}
+	for _, pack := range packs {
+		names = append(names, pack.Name)
+	}
+	return names
+}
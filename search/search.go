@@ -6,52 +6,51 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
-	"sort"
 	"strconv"
 	"strings"
 
-	"time"
-
 	"github.com/Jguer/go-alpm/v2"
-	"github.com/ericm/goncurses"
 	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/index"
 	"github.com/ericm/yup/output"
 	"github.com/ericm/yup/sync"
-	"github.com/mikkeloscar/aur"
+	"github.com/ericm/yup/tui"
 )
 
 var handle *alpm.Handle
 
-// Init alpm
+// Init alpm with the paths and repos from pacman.conf
 func Init() error {
+	conf := config.GetConfig().Pacman
 	var err error
-	handle, err = alpm.Initialize("/", "/var/lib/pacman")
+	handle, err = alpm.Initialize(conf.RootDir, conf.DBPath)
 	if err != nil {
 		return err
 	}
-	return nil
-}
+	if err := handle.SetCacheDirs(conf.CacheDir); err != nil {
+		return err
+	}
+	if err := handle.SetIgnorePkgs(conf.IgnorePkg); err != nil {
+		return err
+	}
 
-func setColor(repo *string) {
-	// Set colour for repo
-	switch *repo {
-	case "core":
-		// Purple
-		*repo = "\033[95mcore\033[0m"
-		break
-	case "extra":
-		// Green
-		*repo = "\033[32mextra\033[0m"
-		break
-	case "community":
-		// Cyan
-		*repo = "\033[36mcommunity\033[0m"
-		break
-	case "multilib":
-		// Yellow
-		*repo = "\033[33mmultilib\033[0m"
-		break
+	level := sigLevel(defaultSigLevel, conf.SigLevel)
+	if err := handle.SetDefaultSigLevel(level); err != nil {
+		return err
 	}
+	for _, repo := range conf.Repos {
+		repoLevel := alpm.SigUseDefault
+		if len(repo.SigLevel) > 0 {
+			repoLevel = sigLevel(level, repo.SigLevel)
+		}
+		db, err := handle.RegisterSyncDB(repo.Name, repoLevel)
+		if err != nil {
+			return err
+		}
+		db.SetServers(repo.Servers)
+		db.SetUsage(usage(repo.Usage))
+	}
+	return nil
 }
 
 // Aur returns []Package parsed from the AUR
@@ -59,201 +58,116 @@ func Aur(query string, print bool, installed bool) ([]output.Package, error) {
 	// Query limit
 	limit := config.GetConfig().UserFile.AurLimit
 
-	db, err := handle.LocalDB()
+	q, err := ParseQuery(query)
 	if err != nil {
 		return []output.Package{}, err
 	}
+	if !q.Source(true) {
+		return []output.Package{}, nil
+	}
 
-	// Generate query
-	queryS := strings.Split(strings.ToLower(query), " ")
-	aurPacks := []aur.Pkg{}
-
-	// Search the AUR
-	aurPackIn, err := aur.Search(query)
+	db, err := handle.LocalDB()
 	if err != nil {
 		return []output.Package{}, err
 	}
 
-	secondaryAur, err := aur.Search(strings.ReplaceAll(query, " ", "-"))
-	aurPackIn = append(aurPackIn, secondaryAur...)
-
-	seen := map[string]bool{}
-	// Filter aurPacksB (before) to aurPacks
-	for _, pack := range aurPackIn {
-		matched := true
-
-		for _, q := range queryS {
-			if !((strings.Contains(strings.ToLower(pack.Name), q) ||
-				strings.Contains(strings.ToLower(pack.Description), q)) &&
-				matched) {
-				matched = false
-			}
+	// Search the AUR
+	var aurPackIn []index.Pkg
+	if !q.byNameDesc() {
+		aurPackIn, err = index.Search(q.Text(), q.By)
+		if err != nil {
+			return []output.Package{}, err
 		}
-
-		if matched &&
-			sort.Search(len(aurPacks),
-				func(i int) bool {
-					return strings.ToLower(aurPacks[i].Name) == strings.ToLower(pack.Name)
-				}) >= len(aurPacks) {
-			if !seen[pack.Name] {
-				seen[pack.Name] = true
-				aurPacks = append(aurPacks, pack)
-			}
+	} else if terms := q.Terms(); len(terms) > 0 {
+		text := strings.Join(terms, " ")
+		aurPackIn, err = index.Search(text, "")
+		if err != nil {
+			return []output.Package{}, err
 		}
 
+		secondaryAur, _ := index.Search(strings.ReplaceAll(text, " ", "-"), "")
+		aurPackIn = append(aurPackIn, secondaryAur...)
+	} else if len(q.Maintainer) > 0 {
+		// The RPC needs something to search for
+		aurPackIn, err = index.Search(q.Maintainer, "maintainer")
+		if err != nil {
+			return []output.Package{}, err
+		}
 	}
 
 	packs := []output.Package{}
-
-	for i, pack := range aurPacks {
-		newPack := output.Package{
-			Aur:         true,
-			Name:        pack.Name,
-			Repo:        "\033[91maur\033[0m",
-			Description: pack.Description,
-			Version:     pack.Version,
-			OutOfDate:   pack.OutOfDate,
-			Upstream:    pack.URL,
+	seen := map[string]bool{}
+	for _, pack := range aurPackIn {
+		if seen[pack.Name] {
+			continue
 		}
+		seen[pack.Name] = true
 
-		// Check if its installed
-		in := db.Pkg(pack.Name)
-		if in != nil {
-			// Is installed
-			newPack.Installed = true
-			newPack.InstalledVersion = in.Version()
-			newPack.InstalledSize = ToString(in.ISize())
-			newPack.InstalledSizeInt = int(in.ISize())
-			newPack.DownloadSize = ToString(in.ISize())
+		newPack := aurPackage(pack, db)
+
+		if !q.Match(newPack) || (installed && !newPack.Installed) {
+			continue
 		}
 
 		if print {
 			output.PrintPackage(newPack)
 		}
 
-		if i < limit {
-			packs = append(packs, newPack)
-		} else {
+		if len(packs) >= limit {
 			return packs, nil
 		}
-
+		packs = append(packs, newPack)
 	}
 	return packs, nil
 }
 
 // Pacman returns []Package parsed from pacman
 func Pacman(query string, print bool, installed bool) ([]output.Package, error) {
-	// Split query
-	search := exec.Command("pacman", append([]string{"-Ss"}, strings.Split(query, " ")...)...)
-	run, err := search.Output()
+	q, err := ParseQuery(query)
 	if err != nil {
+		return []output.Package{}, err
+	}
+	if !q.Source(false) {
 		return []output.Package{}, nil
 	}
 
-	// Find Package vals
-	searchOutput := string(run)
-	pacOut := []string{}
-	last := ""
-	for i, pac := range strings.Split(searchOutput, "\n") {
-		if i%2 == 0 {
-			last = pac
-		} else {
-			pacOut = append(pacOut, fmt.Sprintf("%s\n%s", last, pac))
-		}
+	packs, err := syncSearch(q)
+	if err != nil {
+		return []output.Package{}, err
 	}
-
-	// Regex definitions
-	nameRe := regexp.MustCompile("(?:/)+(\\S+)")
-	repoRe := regexp.MustCompile("^([A-z]+)")
-	versionRe := regexp.MustCompile("^(?:\\S+ ){1}(\\S+)")
-	installedRe := regexp.MustCompile("\\[(.+)\\]")
-	siRe := regexp.MustCompile("(?:\\:)(.+)")
-
-	// Sii regex
-	sizeRe := regexp.MustCompile("Installed Size\\s*?:\\s*?(\\d*.\\d*\\s\\w+)")
-	urlRe := regexp.MustCompile("URL\\s*?:\\s*?(\\s\\S+)")
-	insVerRe := regexp.MustCompile("Version\\s*?:\\s*(\\s\\S+)")
-
-	packs := []output.Package{}
-	for i, pac := range pacOut {
-		if i > config.GetConfig().UserFile.PacmanLimit-1 {
-			// Query limit
-			break
-		}
-		pack := output.Package{
-			Name:        nameRe.FindString(pac)[1:],
-			Repo:        repoRe.FindString(pac),
-			Version:     strings.Split(versionRe.FindString(pac), " ")[1],
-			Installed:   len(installedRe.FindString(strings.Split(pac, "\n")[0])) != 0,
-			Description: strings.Split(pac, "\n")[1][4:],
-		}
-
-		setColor(&pack.Repo)
-
-		if installed {
-			query = "="
-		}
-		if pack.Installed && len(query) > 0 {
-			// Add extra install info
-			// Get info from pacman -Sii package
-			// Add extra install info
-			pacmanSi := exec.Command("pacman", "-Qi", pack.Name)
-			siOut, err := pacmanSi.Output()
-			if err != nil {
-				return []output.Package{}, err
-			}
-
-			// Sets the other vals
-			info := siRe.FindAllString(string(siOut), -1)
-			size := sizeRe.FindAllString(string(siOut), -1)
-			insVer := insVerRe.FindAllString(string(siOut), -1)
-			upstream := urlRe.FindStringSubmatch(string(siOut))
-
-			if len(insVer) > 0 && len(insVer[0]) > 18 {
-				pack.InstalledVersion = insVer[0][18:]
-			}
-			if len(size) > 0 && len(size[0]) > 18 {
-				pack.InstalledSize = size[0][18:]
-			}
-			if len(upstream) > 0 && len(upstream[0]) > 18 {
-				pack.Upstream = urlRe.FindStringSubmatch(string(siOut))[0][18:]
-			}
-			// Checks if index is off and fixes it using a search
-			if pack.InstalledSize == "None" {
-				index := -1
-				spl := strings.Split(string(siOut), "\n")
-				for i, s := range spl {
-					if strings.Contains(s, "Download") {
-						index = len(spl) - i - 2
-						break
-					}
-				}
-				pack.InstalledSize = info[len(info)-index+1][2:]
-				pack.DownloadSize = info[len(info)-index][2:]
-			}
-
-		}
-
-		// Print
-		if print {
+	if installed {
+		packs = filterInstalled(packs)
+	}
+	if limit := config.GetConfig().UserFile.PacmanLimit; len(packs) > limit {
+		packs = packs[:limit]
+	}
+	if print {
+		for _, pack := range packs {
 			output.PrintPackage(pack)
 		}
-
-		if installed && pack.Installed {
-			packs = append(packs, pack)
-		} else if !installed {
-			packs = append(packs, pack)
-		}
-
 	}
-
 	return packs, nil
+}
 
+func filterInstalled(packs []output.Package) []output.Package {
+	out := []output.Package{}
+	for _, pack := range packs {
+		if pack.Installed {
+			out = append(out, pack)
+		}
+	}
+	return out
 }
 
 // PacmanGroups outputs packages for groups matching the term
 func PacmanGroups(term string) ([]output.Package, error) {
-	term = strings.ToLower(term)
+	q, err := ParseQuery(term)
+	if err != nil {
+		return nil, err
+	}
+	if !q.Source(false) || !q.byNameDesc() {
+		return []output.Package{}, nil
+	}
 	pac := exec.Command("pacman", "-Sg")
 	out, err := pac.Output()
 	if err != nil {
@@ -262,9 +176,12 @@ func PacmanGroups(term string) ([]output.Package, error) {
 	outS := string(out)
 	packs := []output.Package{}
 	for _, s := range strings.Split(outS, "\n") {
+		if len(s) == 0 {
+			continue
+		}
 		packs = append(packs, output.Package{
 			Name:        s,
-			Repo:        "\033[94mgroup\033[0m",
+			Repo:        "group",
 			Description: fmt.Sprintf("%s package group", s),
 		})
 	}
@@ -272,7 +189,7 @@ func PacmanGroups(term string) ([]output.Package, error) {
 	outPacks := []output.Package{}
 
 	for _, pack := range packs {
-		if strings.Contains(pack.Name, term) {
+		if q.Match(pack) {
 			outPacks = append(outPacks, pack)
 		}
 	}
@@ -319,73 +236,77 @@ func PacmanQi(arg ...string) ([]output.Package, error) {
 	return out, nil
 }
 
-type sortPack []output.Package
-
-func (s sortPack) Len() int           { return len(s) }
-func (s sortPack) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
-func (s sortPack) Less(i, j int) bool { return s[i].SortValue < s[j].SortValue }
+// Rank sorts packages by the configured sort mode, best match first
+func Rank(queryS string, packs []output.Package) {
+	conf := config.GetConfig().UserFile
+	// The query was checked when searching
+	q, _ := ParseQuery(queryS)
+	sortPackages(q.Text(), packs, conf.SortMode, conf.SortWeights)
+	for i, j := 0, len(packs)-1; i < j; i, j = i+1, j-1 {
+		packs[i], packs[j] = packs[j], packs[i]
+	}
+}
 
 // SortPacks is used to generate the dialogue for yup <query>
 func SortPacks(queryS string, packs []output.Package) {
-	// Replace query spaces with '-'
-	query := strings.ReplaceAll(queryS, " ", "-")
-	querySpl := strings.Split(query, "-")[0]
-
-	if mode := config.GetConfig().UserFile.SortMode; mode == "closest" {
-		// Set sort weighting
-		for i, pack := range packs {
-			// See how close the package name is to the query
-
-			// Check for exact match
-			if packs[i].Name == query {
-				packs[i].SortValue = 1
-				continue
-			}
-
-			name := float64(len(pack.Name))
-			q := float64(len(query))
-
-			// Check for partial match
-			if strings.Contains(pack.Name, query) {
-				packs[i].SortValue = 1 / (name / q)
-				continue
-			}
-
-			// Else one part of the query
-			if strings.Contains(pack.Name, querySpl) {
-				packs[i].SortValue = 1 / (name / q) / 2
-				continue
-			}
-		}
-	}
-
+	// The query was checked when searching
+	q, _ := ParseQuery(queryS)
 	if len(packs) == 0 {
 		output.PrintErr("No results found")
+		if text := q.Text(); len(text) > 0 {
+			err := DidYouMean([]string{text})
+			// Already reported as no results
+			if _, ok := err.(*sync.NotFoundError); !ok && err != nil {
+				output.PrintErr("%s", err)
+			}
+		}
 		return
 	}
 
-	if config.GetConfig().UserFile.SortMode != "none" {
-		sort.Sort(sortPack(packs))
-	}
+	conf := config.GetConfig()
+	sortPackages(q.Text(), packs, conf.UserFile.SortMode, conf.UserFile.SortWeights)
 
 	scanner := bufio.NewReader(os.Stdin)
 	packsToInstall := []output.Package{}
+	// Fuzzy finder to pick with, if one is set
+	finder := config.SelectorCommand(conf.UserFile.Selector, conf.UserFile.SelectorCommand)
+	if conf.JSON {
+		finder = nil
+	}
 Redo:
-	// Prints using ncurses
-	conf := config.GetConfig()
-	if !conf.Ncurses || (conf.UserFile.Ncurses && !conf.Ncurses) {
-		if newPacks, check := printncurses(&packs); check {
-			packsToInstall = newPacks
-		} else if newPacks != nil {
-			// Remove
-			for _, pac := range newPacks {
-				if err := sync.Remove(pac.Name); err != nil {
-					output.PrintErr("%s", err)
-				}
+	if len(finder) > 0 {
+		picked, err := pickFinder(finder, packs)
+		if err != nil {
+			// Fall back to the built-in list, such as when the finder isn't installed
+			output.PrintErr("%s", err)
+			finder = nil
+			goto Redo
+		}
+		if len(picked) == 0 {
+			return
+		}
+		packsToInstall = picked
+	} else if tui.Enabled() {
+		// Prints using ncurses
+		list := &tui.List{
+			Title:    "Or type packages to install",
+			Packages: packs,
+			Remove:   true,
+			Cascade:  removalPlan,
+			Fill:     fillInfo,
+			Pkgbuild: pkgbuild,
+		}
+		for {
+			newPacks, action := list.Run()
+			if action == "install" {
+				packsToInstall = newPacks
+				break
+			} else if action != "remove" {
+				// Quit, the same as picking nothing
+				return
 			}
-			os.Exit(1)
-		} else {
-			os.Exit(1)
+			// Back to the list once they're removed
+			removeFromList(list, newPacks)
 		}
 	} else {
 
@@ -403,59 +324,14 @@ Redo:
 		output.PrintIn("Or type packages to install (eg: 1 2 3, 1-3 or ^4)")
 		input, _ := scanner.ReadString('\n')
 
-		inputs := strings.Split((strings.ToLower(strings.TrimSpace(input))), " ")
-		seen := map[int]bool{}
-		for _, s := range inputs {
-			// 1-3
-			if strings.Contains(s, "-") {
-				if spl := strings.Split(s, "-"); len(spl) == 2 {
-					// Get int vals for range
-					firstT, errF := strconv.Atoi(spl[0])
-					secondT, errS := strconv.Atoi(spl[1])
-					if errF == nil && errS == nil {
-						// Convert range from visual representation
-						first := len(packs) - firstT
-						second := len(packs) - secondT
-						// Filter
-						for i := second; i <= first; i++ {
-							packsToInstall = append(packsToInstall, packs[i])
-						}
-					}
-				}
-				continue
-			}
-			// ^4
-			if strings.Contains(s, "^") {
-				if num, err := strconv.Atoi(s[1:]); err == nil {
-					// Filter for the number
-					for i, pack := range packs {
-						ind := len(packs) - i
-						if ind == num {
-							continue
-						}
-						packsToInstall = append(packsToInstall, pack)
-					}
-				}
-				continue
-			}
-
-			if num, err := strconv.Atoi(s); err == nil {
-				// Find package from input
-				index := len(packs) - num
-				// Add to the slice
-				if index < len(packs) && index >= 0 && !seen[index] {
-					packsToInstall = append(packsToInstall, packs[index])
-					seen[index] = true
-				}
-			}
-		}
+		packsToInstall = pickNumbers(input, packs)
 		packs = packsToInstall
 
 	}
 	// Print packs
 	output.Printf("The following packages will be installed:")
 	for i, pack := range packsToInstall {
-		fmt.Printf("    %-2d \033[1m%s\033[0m %s (%s)\n", i+1, pack.Name, pack.Version, pack.Repo)
+		fmt.Printf("    %-2d \033[1m%s\033[0m %s (%s)\n", i+1, pack.Name, pack.Version, output.RepoColor(pack.Repo))
 	}
 
 	if conf.UserFile.AskRedo {
@@ -472,559 +348,72 @@ Redo:
 
 	// Then, install the packages
 	for _, pack := range packsToInstall {
+		if pack.Repo == "group" {
+			if err := installGroup(pack.Name); err != nil {
+				output.PrintErr("%s", err)
+			}
+			continue
+		}
 		sync.Sync([]string{pack.Name}, pack.Aur, false)
 	}
 
 }
 
-func getDims() (string, string) {
-	var (
-		prevMy string
-		prevMx string
-	)
-	if prevMyB, err := exec.Command("tput", "lines").Output(); err == nil {
-		prevMy = string(prevMyB)
-	}
-	if prevMxB, err := exec.Command("tput", "cols").Output(); err == nil {
-		prevMx = string(prevMxB)
-	}
-
-	return prevMx, prevMy
-}
-
-// Prints ncurses
-func printncurses(packs *[]output.Package) ([]output.Package, bool) {
-	selected := 1
-	checked := map[int]bool{}
-
-Resize:
-	stdscr, err := goncurses.Init()
-	if err != nil {
-		output.PrintErr("%s", err)
-	}
-	stdscr.Keypad(true)
-	defer goncurses.End()
-
-	goncurses.Cursor(0)
-	goncurses.Echo(false)
-	goncurses.Raw(true)
-
-	goncurses.MouseMask(goncurses.M_ALL, nil) // temporarily enable all mouse clicks
-	// Init the ncurses colours
-	goncurses.StartColor()
-	goncurses.UseDefaultColors()
-	goncurses.InitPair(1, goncurses.C_RED, -1)
-	goncurses.InitPair(11, goncurses.C_RED, goncurses.C_WHITE)
-	goncurses.InitPair(2, goncurses.C_CYAN, -1)
-	goncurses.InitPair(12, goncurses.C_CYAN, goncurses.C_WHITE)
-	goncurses.InitPair(3, goncurses.C_YELLOW, -1)
-	goncurses.InitPair(13, goncurses.C_YELLOW, goncurses.C_WHITE)
-	goncurses.InitPair(4, goncurses.C_GREEN, -1)
-	goncurses.InitPair(14, goncurses.C_GREEN, goncurses.C_WHITE)
-	goncurses.InitPair(5, goncurses.C_MAGENTA, -1)
-	goncurses.InitPair(15, goncurses.C_MAGENTA, goncurses.C_WHITE)
-	goncurses.InitPair(6, goncurses.C_WHITE, -1)
-	goncurses.InitPair(16, goncurses.C_WHITE, goncurses.C_WHITE)
-	// Selected
-	goncurses.InitPair(7, goncurses.C_BLACK, goncurses.C_WHITE)
-	goncurses.InitPair(9, goncurses.C_BLACK, goncurses.C_MAGENTA)
-
-	// Menu
-	goncurses.InitPair(8, goncurses.C_BLUE, -1)
-
-	goncurses.InitPair(10, goncurses.C_BLACK, goncurses.C_WHITE)
-
-	// Event loop
-	// Setup ncurses
-	var ch goncurses.Key
-	var offset int
-	var timeout bool
-	var newSel int
-	var notSel bool
-	var toSel int
-	prevMy := ""
-	prevMx := ""
-
-	// Initial print
-	printPacks(stdscr, packs, selected, checked)
-	printBar(stdscr, 0, 0, false)
-	printhelp(stdscr)
-
-	stdscr.Refresh()
-
-	for ch != 'q' && ch != 27 {
-		update := false
-		if !timeout {
-		Sw:
-			switch goncurses.Key(ch) {
-			case 339: // PAGE UP
-				if !(selected == len(*packs)) {
-					rows, _ := stdscr.MaxYX()
-					selected += (rows - 3) / 2
-					if selected > len(*packs) {
-						selected = len(*packs)
-					}
-					update = true
-				}
-			case 338: // PAGE DOWN
-				if !(selected == 1) {
-					rows, _ := stdscr.MaxYX()
-					selected -= (rows - 3) / 2
-					if selected < 1 {
-						selected = 1
-					}
-					update = true
-				}
-			case goncurses.KEY_END:
-				if selected != 1 {
-					selected = 1
-					update = true
-				}
-			case goncurses.KEY_HOME:
-				if selected != len(*packs) {
-					selected = len(*packs)
-					update = true
-				}
-			case 'k':
-				if config.GetConfig().UserFile.VimKeybindings && selected < len(*packs) {
-					// Scroll forward
-					selected++
-					update = true
-				}
-			case 'j':
-				if config.GetConfig().UserFile.VimKeybindings && selected > 1 {
-					// Scroll backward
-					selected--
-					update = true
-				}
-			case goncurses.KEY_UP, goncurses.KEY_SF, 'w':
-				// Scroll forward
-				if selected < len(*packs) {
-					selected++
-					update = true
-				}
-			case goncurses.KEY_DOWN, goncurses.KEY_SR, 's':
-				// Scroll backward
-				if selected > 1 {
-					selected--
-					update = true
-				}
-			case goncurses.KEY_MOUSE:
-				if ms := goncurses.GetMouse(); ms != nil {
-					if ms.State == goncurses.M_B1_CLICKED {
-						clicked := -1
-						my, _ := stdscr.MaxYX()
-						clicked = getactive(ms.Y, my, offset, selected, packs)
-						if clicked != -1 {
-							checked[clicked] = !checked[clicked]
+// pickNumbers returns the packages chosen with input like 1 2 3, 1-3 or ^4.
+// Numbers count up from the bottom of the list
+func pickNumbers(input string, packs []output.Package) []output.Package {
+	picked := []output.Package{}
+	inputs := strings.Split((strings.ToLower(strings.TrimSpace(input))), " ")
+	seen := map[int]bool{}
+	for _, s := range inputs {
+		// 1-3
+		if strings.Contains(s, "-") {
+			if spl := strings.Split(s, "-"); len(spl) == 2 {
+				// Get int vals for range
+				firstT, errF := strconv.Atoi(spl[0])
+				secondT, errS := strconv.Atoi(spl[1])
+				if errF == nil && errS == nil {
+					// Convert range from visual representation
+					first := len(packs) - firstT
+					second := len(packs) - secondT
+					// Filter
+					for i := second; i <= first; i++ {
+						if i >= 0 && i < len(packs) && !seen[i] {
+							picked = append(picked, packs[i])
+							seen[i] = true
 						}
-					} else if ms.State == goncurses.M_B4_PRESSED {
-						// Scroll up
-						ch = goncurses.KEY_SF
-						goto Sw
-					} else if ms.State == goncurses.M_B5_PRESSED {
-						// Scroll down
-						ch = goncurses.KEY_SR
-						goto Sw
 					}
-					update = true
-				}
-
-			case '\n', ' ':
-				if notSel {
-					for i := 0; i <= len(*packs); i++ {
-						if i == newSel {
-							continue
-						}
-						checked[i] = true
-					}
-					update = true
-					notSel = false
-					toSel = 0
-					newSel = 0
-					break
-				}
-
-				if newSel != 0 {
-					if toSel != 0 {
-						if toSel > newSel {
-							for i := newSel; i <= toSel; i++ {
-								checked[i] = true
-								update = true
-							}
-							selected = toSel
-						} else if toSel < newSel {
-							for i := toSel; i <= newSel; i++ {
-								checked[i] = true
-								update = true
-							}
-							selected = newSel
-						}
-					} else {
-						num := newSel
-						if num > 0 && num < len(*packs) {
-							checked[num] = !checked[num]
-							selected = num
-							update = true
-						}
-					}
-
-				} else {
-					checked[selected] = !checked[selected]
-					update = true
-				}
-				newSel = 0
-				toSel = 0
-
-			case 'u':
-				cm := exec.Command("xdg-open", (*packs)[len(*packs)-selected].Upstream)
-				cm.Run()
-
-			case 'i', 'z', 'r':
-				// Filter packs
-				newPack := []output.Package{}
-				for i, pack := range *packs {
-					if checked[len(*packs)-i] {
-						newPack = append(newPack, pack)
-					}
-				}
-
-				// Check if none selected
-				if len(newPack) == 0 {
-					newPack = append(newPack, (*packs)[len(*packs)-selected])
-				}
-
-				if ch == 'r' {
-					return newPack, false
-				}
-				return newPack, true
-
-			case '-':
-				if newSel != 0 && !notSel {
-					toSel = newSel
-					newSel = 0
-					update = true
-				}
-
-			case '^':
-				if !notSel {
-					notSel = true
-					update = true
-				}
-			case 'f':
-				goncurses.End()
-				goto Resize
-
-			default:
-				if num, err := strconv.Atoi(string(ch)); err == nil {
-					if newSel != 0 {
-						newSel = newSel*10 + num
-					} else {
-						newSel = num
-					}
-					update = true
 				}
 			}
-
-		}
-
-		// Get prev size
-		if prevMx == "" || prevMy == "" {
-			prevMx, prevMy = getDims()
-		} else if mmx, mmy := getDims(); prevMx != mmx || prevMy != mmy {
-			goncurses.End()
-			goto Resize
-		}
-		// Mouse timeout
-		timeout = true
-		go func(timeout *bool) {
-			time.Sleep(30 * time.Millisecond)
-			*timeout = false
-		}(&timeout)
-		if update {
-			stdscr.Clear()
-			offset = printPacks(stdscr, packs, selected, checked)
-			printBar(stdscr, newSel, toSel, notSel)
-			printhelp(stdscr)
-		}
-		ch = stdscr.GetChar()
-
-	}
-	return nil, false
-}
-
-func getactive(y, my, offset, selected int, packs *[]output.Package) int {
-	if y >= my-3 {
-		return -1
-	}
-
-	for i := range *packs {
-		ind := len(*packs) - i - 3
-		iy := my - (2 * (len(*packs) - i)) + offset + 3
-
-		if y == iy || y == iy+1 {
-			return ind
-		}
-
-	}
-	return -1
-}
-
-func printBar(stdscr *goncurses.Window, newSel, toSel int, notSel bool) int {
-	my, mx := stdscr.MaxYX()
-
-	// Print line
-	stdscr.ColorOn(8)
-	line := ""
-	for _i := 0; _i < mx; _i++ {
-		line += "="
-	}
-	stdscr.MovePrintf(my-3, 0, "%s", line)
-	stdscr.ColorOff(8)
-
-	// Print Input
-	stdscr.ColorOn(5)
-	stdscr.MovePrint(my-2, 0, "==>")
-	stdscr.ColorOff(5)
-	stdscr.MovePrint(my-2, 4, "Click on a package above, use the arrow keys and enter")
-
-	stdscr.ColorOn(4)
-	stdscr.MovePrint(my-1, 0, "==> Or type packages to install (eg: 1 2 3, 1-3 or ^4):")
-	stdscr.ColorOff(4)
-
-	// Print user input
-	cur := 56
-	if notSel {
-		stdscr.MovePrint(my-1, cur, "^")
-		cur++
-	}
-	if toSel != 0 {
-		ts := strconv.Itoa(toSel)
-		stdscr.MovePrintf(my-1, cur, ts+"-")
-		cur += len(ts) + 1
-	}
-	if newSel != 0 {
-		stdscr.MovePrint(my-1, cur, newSel)
-	}
-
-	return my
-}
-
-func printPacks(stdscr *goncurses.Window, packs *[]output.Package, selected int, checked map[int]bool) int {
-	my, mx := stdscr.MaxYX()
-	// Calculate offset up
-	offset := 0
-	if selected*2 > my-5 {
-		offset = selected*2 - my + 5
-	}
-	for i, item := range *packs {
-		ind := len(*packs) - i
-		sel := ind == selected
-		check := checked[ind]
-		y := my - (2 * (len(*packs) - i)) - 3 + offset
-
-		if y > my-4 {
 			continue
 		}
-		// Number
-		if sel {
-			stdscr.ColorOn(7)
-		} else if check {
-			stdscr.ColorOn(9)
-		}
-		stdscr.AttrOn(goncurses.A_BOLD)
-		if check {
-			stdscr.MovePrintf(y, 0, "%-5s", fmt.Sprintf("(%d)", len(*packs)-i))
-		} else {
-			stdscr.MovePrintf(y, 0, "(%d)", len(*packs)-i)
-		}
-		stdscr.AttrOff(goncurses.A_BOLD)
-		if sel {
-			stdscr.ColorOff(7)
-		} else if check {
-			stdscr.ColorOff(9)
-		}
-
-		cur := 5
-
-		// Repo
-		switch item.Repo {
-		case "\033[91maur\033[0m":
-			cur += 3
-			if check {
-				stdscr.ColorOn(9)
-			} else {
-				stdscr.ColorOn(1)
-			}
-			stdscr.MovePrint(y, 5, "aur")
-			if check {
-				stdscr.ColorOff(9)
-			} else {
-				stdscr.ColorOff(1)
-			}
-		case "\033[95mcore\033[0m":
-			cur += 4
-			if check {
-				stdscr.ColorOn(9)
-			} else {
-				stdscr.ColorOn(5)
-			}
-			stdscr.MovePrint(y, 5, "core")
-			if check {
-				stdscr.ColorOff(9)
-			} else {
-				stdscr.ColorOff(5)
-			}
-		case "\033[32mextra\033[0m":
-			cur += 5
-			if check {
-				stdscr.ColorOn(9)
-			} else {
-				stdscr.ColorOn(4)
-			}
-			stdscr.MovePrint(y, 5, "extra")
-			if check {
-				stdscr.ColorOff(9)
-			} else {
-				stdscr.ColorOff(4)
-			}
-		case "\033[36mcommunity\033[0m":
-			cur += 9
-			if check {
-				stdscr.ColorOn(9)
-			} else {
-				stdscr.ColorOn(2)
-			}
-			stdscr.MovePrint(y, 5, "community")
-			if check {
-				stdscr.ColorOff(9)
-			} else {
-				stdscr.ColorOff(2)
-			}
-		case "\033[33mmultilib\033[0m":
-			cur += 8
-			if check {
-				stdscr.ColorOn(9)
-			} else {
-				stdscr.ColorOn(3)
-			}
-			stdscr.MovePrint(y, 5, "multilib")
-			if check {
-				stdscr.ColorOff(9)
-			} else {
-				stdscr.ColorOff(3)
-			}
-		case "\033[94mgroup\033[0m":
-			cur += 5
-			if check {
-				stdscr.ColorOn(9)
-			} else {
-				stdscr.ColorOn(8)
-			}
-			stdscr.MovePrint(y, 5, "group")
-			if check {
-				stdscr.ColorOff(9)
-			} else {
-				stdscr.ColorOff(8)
-			}
-		default:
-			cur += len(item.Repo)
-			if check {
-				stdscr.ColorOn(9)
-			}
-			stdscr.MovePrint(y, 5, item.Repo)
-			if check {
-				stdscr.ColorOff(9)
+		// ^4
+		if strings.Contains(s, "^") {
+			if num, err := strconv.Atoi(s[1:]); err == nil {
+				// Filter for the number
+				for i, pack := range packs {
+					ind := len(packs) - i
+					if ind == num || seen[i] {
+						continue
+					}
+					picked = append(picked, pack)
+					seen[i] = true
+				}
 			}
+			continue
 		}
 
-		// Slash
-		if check {
-			stdscr.ColorOn(9)
-		}
-		stdscr.AttrOn(goncurses.A_DIM)
-		stdscr.MovePrint(y, cur, "/")
-		cur++
-		stdscr.AttrOff(goncurses.A_DIM)
-		if check {
-			stdscr.ColorOff(9)
-		}
-
-		if check {
-			stdscr.ColorOn(9)
-		}
-		// Name
-		stdscr.AttrOn(goncurses.A_BOLD)
-		stdscr.MovePrint(y, cur, item.Name)
-		cur += len(item.Name) + 1
-		stdscr.AttrOff(goncurses.A_BOLD)
-
-		if check {
-			stdscr.ColorOff(9)
-		}
-
-		// Version
-		stdscr.MovePrint(y, cur, item.Version)
-		cur += len(item.Version) + 1
-
-		// Installed
-		if item.Installed {
-			stdscr.MovePrint(y, cur, "(")
-			cur++
-			stdscr.AttrOn(goncurses.A_BOLD)
-			stdscr.ColorOn(5)
-			stdscr.MovePrint(y, cur, "INSTALLED")
-			cur += 9
-			if item.InstalledVersion != item.Version {
-				// Outdated
-				cur++
-				stdscr.MovePrint(y, cur, "OUTDATED")
-				stdscr.ColorOff(5)
-				stdscr.AttrOff(goncurses.A_BOLD)
-				cur += 9
-				stdscr.MovePrint(y, cur, item.InstalledVersion)
-				cur += len(item.InstalledVersion)
+		if num, err := strconv.Atoi(s); err == nil {
+			// Find package from input
+			index := len(packs) - num
+			// Add to the slice
+			if index < len(packs) && index >= 0 && !seen[index] {
+				picked = append(picked, packs[index])
+				seen[index] = true
 			}
-			stdscr.ColorOff(5)
-			stdscr.AttrOff(goncurses.A_BOLD)
-			stdscr.MovePrint(y, cur, ")")
-			// Size
-			cur += 2
-			stdscr.MovePrintf(y, cur, "Install Size: %s", item.InstalledSize)
-			cur += 14 + len(item.InstalledSize)
-		}
-
-		// Out of date
-		if item.OutOfDate != 0 {
-			stdscr.AttrOn(goncurses.A_BOLD)
-			stdscr.ColorOn(1)
-			stdscr.MovePrintf(y, cur, "(OUT OF DATE)")
-			stdscr.ColorOff(1)
-			stdscr.AttrOff(goncurses.A_BOLD)
-			cur += 13
 		}
-
-		// Description
-		desc := item.Description
-		if len(desc) > mx-7 {
-			desc = desc[:(mx-9)] + ".."
-		}
-		stdscr.MovePrintf(y+1, 5, "- %s", desc)
 	}
-
-	return offset
-}
-
-// Help
-func printhelp(stdscr *goncurses.Window) {
-	_, mx := stdscr.MaxYX()
-	stdscr.ColorOn(10)
-	stdscr.MovePrintf(0, mx-15, " %-14s", "Enter: Select")
-	stdscr.MovePrintf(1, mx-15, " %-14s", "I/Z: Install")
-	stdscr.MovePrintf(2, mx-15, " %-14s", "R: Remove")
-	stdscr.MovePrintf(3, mx-15, " %-14s", "U: Upstream")
-	stdscr.MovePrintf(4, mx-15, " %-14s", "Q: Quit")
-	stdscr.ColorOff(10)
+	return picked
 }
 
 // ToBytes Turns 1 KiB into 1024
@@ -1048,21 +437,5 @@ func ToBytes(data string) int {
 
 // ToString Turns 1024 into 1.00 KiB
 func ToString(dataI int64) string {
-	b := float32(1024)
-	data := float32(dataI)
-	i := 0
-	for data != 0 && data > 1024 {
-		data /= b
-		i++
-	}
-	switch i {
-	case 1:
-		return fmt.Sprintf("%.2f %s", data, "KiB")
-	case 2:
-		return fmt.Sprintf("%.2f %s", data, "MiB")
-	case 3:
-		return fmt.Sprintf("%.2f %s", data, "GiB")
-	default:
-		return fmt.Sprintf("%.2f B", data)
-	}
+	return output.FormatSize(dataI)
 }
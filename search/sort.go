@@ -0,0 +1,143 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/fuzzy"
+	"github.com/ericm/yup/output"
+)
+
+// Default repo order, best first. Other repos go after these
+var repoOrder = []string{"core", "extra", "community", "multilib"}
+
+// sortPackages scores packs against the query and sorts them so the best match is last
+func sortPackages(text string, packs []output.Package, mode string, w config.SortWeights) {
+	// Replace query spaces with '-'
+	query := strings.ToLower(strings.ReplaceAll(text, " ", "-"))
+
+	switch mode {
+	case "none":
+		return
+	case "name":
+		sort.SliceStable(packs, func(i, j int) bool { return packs[i].Name < packs[j].Name })
+		return
+	case "weighted":
+		for i := range packs {
+			packs[i].SortValue = weighted(query, packs[i], w)
+		}
+	case "votes":
+		for i := range packs {
+			packs[i].SortValue = float64(packs[i].Votes)
+		}
+	case "popularity":
+		for i := range packs {
+			packs[i].SortValue = packs[i].Popularity
+		}
+	case "size":
+		for i := range packs {
+			packs[i].SortValue = float64(packs[i].Size)
+			if size := float64(packs[i].InstalledSizeInt); size > packs[i].SortValue {
+				packs[i].SortValue = size
+			}
+		}
+	case "repo":
+		// Closest match within each repo
+		closest(query, packs)
+		for i := range packs {
+			packs[i].SortValue += repoPriority(packs[i].Repo) * 10
+		}
+	default:
+		closest(query, packs)
+	}
+
+	sort.SliceStable(packs, func(i, j int) bool {
+		if packs[i].SortValue != packs[j].SortValue {
+			return packs[i].SortValue < packs[j].SortValue
+		}
+		// Keep equal scores in a stable, readable order
+		return packs[i].Name > packs[j].Name
+	})
+}
+
+// closest scores by how much of the name the query covers
+func closest(query string, packs []output.Package) {
+	querySpl := strings.Split(query, "-")[0]
+	for i, pack := range packs {
+		packs[i].SortValue = 0
+
+		// Check for exact match
+		if pack.Name == query {
+			packs[i].SortValue = 1
+			continue
+		}
+
+		name := float64(len(pack.Name))
+		q := float64(len(query))
+
+		// Check for partial match
+		if strings.Contains(pack.Name, query) {
+			packs[i].SortValue = 1 / (name / q)
+			continue
+		}
+
+		// Else one part of the query
+		if strings.Contains(pack.Name, querySpl) {
+			packs[i].SortValue = 1 / (name / q) / 2
+		}
+	}
+}
+
+// weighted combines several signals, each scaled to 0-1, by the configured weights
+func weighted(query string, pack output.Package, w config.SortWeights) float64 {
+	name := strings.ToLower(pack.Name)
+
+	score := w.Distance * fuzzy.Similarity(query, name)
+	score += w.Tokens * tokenMatch(query, name)
+	score += w.Votes * math.Min(math.Log10(float64(pack.Votes)+1)/4, 1)
+	score += w.Popularity * math.Min(math.Log10(pack.Popularity+1)/2, 1)
+	score += w.Repo * repoPriority(pack.Repo)
+	if pack.Installed {
+		score += w.Installed
+	}
+	return score
+}
+
+// tokenMatch returns the share of query words that are whole words of the name
+func tokenMatch(query, name string) float64 {
+	split := func(r rune) bool { return r == '-' || r == '_' || r == '.' || r == ' ' }
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(name, split) {
+		words[word] = true
+	}
+
+	tokens := strings.FieldsFunc(query, split)
+	if len(tokens) == 0 {
+		return 0
+	}
+	matched := 0
+	for _, token := range tokens {
+		if words[token] {
+			matched++
+		}
+	}
+	return float64(matched) / float64(len(tokens))
+}
+
+// repoPriority ranks official repos first, then third party repos, groups and the AUR
+func repoPriority(repo string) float64 {
+	for i, name := range repoOrder {
+		if repo == name {
+			return 1 - float64(i)*0.1
+		}
+	}
+	switch repo {
+	case "aur":
+		return 0.2
+	case "group":
+		return 0.4
+	}
+	return 0.5
+}
@@ -0,0 +1,62 @@
+package search
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/output"
+)
+
+var update = flag.Bool("update", false, "update the golden sort results")
+
+// TestSortGolden ranks a fixed corpus in every mode and compares against testdata.
+// Run with -update after an intended ranking change
+func TestSortGolden(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "sort_corpus.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	corpus := struct {
+		Query    string
+		Packages []output.Package
+	}{}
+	if err := json.Unmarshal(data, &corpus); err != nil {
+		t.Fatal(err)
+	}
+
+	weights := config.SortWeights{Distance: 4, Tokens: 2, Votes: 1, Popularity: 1, Installed: 0.5, Repo: 1}
+	got := map[string][]string{}
+	for _, mode := range []string{"closest", "none", "weighted", "votes", "popularity", "name", "repo", "size"} {
+		packs := append([]output.Package{}, corpus.Packages...)
+		sortPackages(corpus.Query, packs, mode, weights)
+		for _, pack := range packs {
+			got[mode] = append(got[mode], pack.Name)
+		}
+	}
+
+	golden := filepath.Join("testdata", "sort_golden.json")
+	if *update {
+		out, _ := json.MarshalIndent(got, "", "\t")
+		if err := ioutil.WriteFile(golden, append(out, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err = ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{}
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	for mode, names := range want {
+		if !reflect.DeepEqual(got[mode], names) {
+			t.Errorf("%s:\n got %v\nwant %v", mode, got[mode], names)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package search
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ericm/yup/fuzzy"
+	"github.com/ericm/yup/index"
+	"github.com/ericm/yup/output"
+	"github.com/ericm/yup/sync"
+)
+
+// Number of names suggested for a missing target
+const suggestLimit = 5
+
+// candidate is a package that may be suggested.
+// pack builds it, so only the suggested ones are converted
+type candidate struct {
+	name string
+	// Whether it provides the missing name
+	provides bool
+	pack     func() output.Package
+}
+
+// Suggest returns packages from the repos and the AUR with names close to name,
+// followed by the ones providing it
+func Suggest(name string) ([]output.Package, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) == 0 {
+		return nil, nil
+	}
+
+	dbs, err := handle.SyncDBs()
+	if err != nil {
+		return nil, err
+	}
+	local, err := handle.LocalDB()
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []candidate{}
+	for _, db := range dbs.Slice() {
+		repo := db.Name()
+		for _, pkg := range db.PkgCache().Slice() {
+			pkg := pkg
+			provides := false
+			for _, dep := range pkg.Provides().Slice() {
+				if strings.ToLower(dep.Name) == name {
+					provides = true
+					break
+				}
+			}
+			candidates = append(candidates, candidate{
+				name:     pkg.Name(),
+				provides: provides,
+				pack:     func() output.Package { return syncPackage(repo, pkg, local) },
+			})
+		}
+	}
+
+	aurCandidate := func(pack index.Pkg, provides bool) candidate {
+		return candidate{name: pack.Name, provides: provides, pack: func() output.Package { return aurPackage(pack, local) }}
+	}
+	if names := index.Names(); names != nil {
+		// Only the names are needed from the index until the closest are picked
+		for _, aurName := range names {
+			aurName := aurName
+			candidates = append(candidates, candidate{name: aurName, pack: func() output.Package {
+				infos, _ := index.Info([]string{aurName})
+				if len(infos) == 0 {
+					return output.Package{Name: aurName, Repo: "aur", Aur: true}
+				}
+				return aurPackage(infos[0], local)
+			}})
+		}
+	} else {
+		// Without the index, the AUR can only be searched by substring,
+		// so look up the longest word of the name
+		query := ""
+		for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == '-' || r == '_' || r == ' ' }) {
+			if len(word) > len(query) {
+				query = word
+			}
+		}
+		if len(query) >= 2 {
+			found, err := index.Search(query, "name")
+			if err != nil {
+				return nil, err
+			}
+			for _, pack := range found {
+				candidates = append(candidates, aurCandidate(pack, false))
+			}
+		}
+	}
+	if provides, err := index.Search(name, "provides"); err == nil {
+		for _, pack := range provides {
+			candidates = append(candidates, aurCandidate(pack, true))
+		}
+	}
+
+	return suggestions(name, candidates), nil
+}
+
+// suggestions picks the candidates named closest to name, then those providing it
+func suggestions(name string, candidates []candidate) []output.Package {
+	byName := map[string]candidate{}
+	names := []string{}
+	for _, cand := range candidates {
+		if _, ok := byName[cand.name]; !ok {
+			byName[cand.name] = cand
+			names = append(names, cand.name)
+		}
+	}
+
+	maxDist := len(name) / 3
+	if maxDist < 2 {
+		maxDist = 2
+	}
+	out := []output.Package{}
+	seen := map[string]bool{}
+	for _, closest := range fuzzy.Closest(name, names, suggestLimit, maxDist) {
+		out = append(out, byName[closest].pack())
+		seen[closest] = true
+	}
+	for _, cand := range candidates {
+		if cand.provides && !seen[cand.name] {
+			out = append(out, cand.pack())
+			seen[cand.name] = true
+		}
+	}
+	return out
+}
+
+// DidYouMean offers to install a close match for each target that wasn't found
+func DidYouMean(names []string) error {
+	scanner := bufio.NewReader(os.Stdin)
+	missing := []string{}
+	for _, name := range names {
+		packs, err := Suggest(name)
+		if err != nil {
+			return err
+		}
+		if len(packs) == 0 {
+			missing = append(missing, name)
+			continue
+		}
+
+		output.Printf("Couldn't find \033[1m%s\033[0m. Did you mean:", name)
+		for i, pack := range packs {
+			fmt.Print("\033[37m\033[1m")
+			fmt.Printf("%-5s", fmt.Sprintf("(%d)", len(packs)-i))
+			fmt.Print("\033[0m")
+			output.PrintPackage(pack, "def")
+		}
+		output.PrintIn("Install one of these? (eg: 1 2 3, 1-3 or ^4, Enter to skip)")
+		input, _ := scanner.ReadString('\n')
+		for _, pack := range pickNumbers(input, packs) {
+			if err := sync.Sync([]string{pack.Name}, pack.Aur, false); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return &sync.NotFoundError{Names: missing}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/ericm/yup/index"
+	"github.com/ericm/yup/output"
+)
+
+func TestSuggestions(t *testing.T) {
+	packs := []struct {
+		name     string
+		provides []string
+	}{
+		{"visual-studio-code-bin", nil},
+		{"visual-studio-code-insiders-bin", nil},
+		{"code", []string{"vscode"}},
+		{"vscodium-bin", []string{"vscode=1.50"}},
+		{"neovim", nil},
+	}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"visual-studio-code-bn", []string{"visual-studio-code-bin"}},
+		{"vscode", []string{"code", "vscodium-bin"}},
+		{"emacs", []string{}},
+	}
+	for _, test := range tests {
+		built := 0
+		candidates := []candidate{}
+		for _, pack := range packs {
+			name, provides := pack.name, false
+			for _, provide := range pack.provides {
+				provides = provides || index.DepName(provide) == test.name
+			}
+			candidates = append(candidates, candidate{name: name, provides: provides, pack: func() output.Package {
+				built++
+				return output.Package{Name: name}
+			}})
+		}
+
+		got := suggestions(test.name, candidates)
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for i, pack := range got {
+			if pack.Name != test.want[i] {
+				t.Errorf("%s: got %s at %d, want %s", test.name, pack.Name, i, test.want[i])
+			}
+		}
+		// Only what's suggested is built
+		if built != len(got) {
+			t.Errorf("%s: built %d packages", test.name, built)
+		}
+	}
+}
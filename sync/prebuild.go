@@ -0,0 +1,305 @@
+package sync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/index"
+	"github.com/ericm/yup/output"
+)
+
+const prebuiltDir = "prebuilt"
+
+// Lines of a PKGBUILD that a plain version bump changes: the version fields and quoted checksums
+var bumpLine = regexp.MustCompile(`^(pkgver=['"]?[\w.+~-]+['"]?|pkgrel=['"]?[\d.]+['"]?|epoch=['"]?\d+['"]?|` +
+	`(\w*sums(_\w+)?=\(?)?(\s*['"]([[:xdigit:]]{32,}|SKIP)['"])*\s*\)?)$`)
+
+// Prebuild fetches and builds AUR packages without installing them.
+// Only allowlisted bases, commits the user has reviewed and version bumps of those are built
+func Prebuild(packages []string) error {
+	if len(packages) == 0 {
+		output.Printf("Found no AUR packages to prebuild")
+		return nil
+	}
+	conf := config.GetConfig()
+	state, err := config.ReadState()
+	if err != nil {
+		return err
+	}
+
+	repo, err := index.Info(packages)
+	if err != nil {
+		return err
+	}
+
+	dest := filepath.Join(conf.CacheDir, prebuiltDir)
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, pack := range repo {
+		base := pack.PackageBase
+		if seen[base] {
+			continue
+		}
+		seen[base] = true
+
+		dir := filepath.Join(conf.CacheDir, base)
+		commit, err := fetchBase(base, dir)
+		if err != nil {
+			output.PrintErr("Failed to fetch %s: %s", base, err)
+			continue
+		}
+		if built, ok := state.Prebuilt[base]; ok && built.Commit == commit {
+			continue
+		}
+		diff := func(from, to string) (string, error) {
+			git := exec.Command("git", "diff", "-U0", "--no-color", "--no-ext-diff", from, to)
+			git.Dir = dir
+			out, err := git.Output()
+			return string(out), err
+		}
+		if !trusted(base, commit, state, diff) {
+			output.Printf("Skipping \033[1m%s\033[0m: commit %.8s hasn't been reviewed", base, commit)
+			continue
+		}
+
+		output.Printf("Prebuilding \033[1m\033[32m%s\033[39m\033[2m %s\033[0m", base, pack.Version)
+		files, err := buildBase(dir, filepath.Join(dest, "src", base), commit, dest)
+		if err != nil {
+			output.PrintErr("Failed to build %s: %s", base, err)
+			continue
+		}
+		built := config.Prebuilt{Commit: commit, Version: pack.Version, Files: files}
+		err = config.UpdateState(func(state *config.State) {
+			if state.Prebuilt == nil {
+				state.Prebuilt = map[string]config.Prebuilt{}
+			}
+			state.Prebuilt[base] = built
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchBase clones or fetches a package base and returns the commit that would be built
+func fetchBase(base, dir string) (string, error) {
+	var git *exec.Cmd
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		git = exec.Command("git", "clone", "https://aur.archlinux.org/"+base+".git", dir)
+	} else {
+		git = exec.Command("git", "fetch")
+		git.Dir = dir
+	}
+	if err := git.Run(); err != nil {
+		return "", err
+	}
+	return revParse(dir, "origin/master")
+}
+
+// buildBase builds a commit of the clone in dir into dest without installing or prompting.
+// It's built in its own worktree so the clone yup installs from is left alone
+func buildBase(dir, worktree, commit, dest string) ([]string, error) {
+	var checkout *exec.Cmd
+	if _, err := os.Stat(worktree); os.IsNotExist(err) {
+		checkout = exec.Command("git", "worktree", "add", "--force", "--detach", worktree, commit)
+		checkout.Dir = dir
+	} else {
+		checkout = exec.Command("git", "checkout", "--force", "--detach", commit)
+		checkout.Dir = worktree
+	}
+	if err := checkout.Run(); err != nil {
+		return nil, err
+	}
+	dir = worktree
+
+	env := append(os.Environ(), "PKGDEST="+dest)
+	build := exec.Command("makepkg", "--noconfirm", "--nodeps", "-cf")
+	build.Dir, build.Env = dir, env
+	output.SetStd(build)
+	build.Stdin = nil
+	if err := build.Run(); err != nil {
+		return nil, err
+	}
+
+	list := exec.Command("makepkg", "--packagelist")
+	list.Dir, list.Env = dir, env
+	out, err := list.Output()
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, file := range strings.Split(string(out), "\n") {
+		if _, err := os.Stat(file); len(file) > 0 && err == nil {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// trusted checks if a commit of a base may be built unattended.
+// That is if the base is in PrebuildAllow, the user reviewed the commit, or the only
+// changes since the commit they reviewed are to pkgver, pkgrel, epoch, checksums and .SRCINFO.
+// diff returns the changes between two commits
+func trusted(base, commit string, state *config.State, diff func(from, to string) (string, error)) bool {
+	for _, name := range config.GetConfig().UserFile.PrebuildAllow {
+		if name == base {
+			return true
+		}
+	}
+	reviewed, ok := state.Reviewed[base]
+	if !ok {
+		return false
+	}
+	if reviewed == commit {
+		return true
+	}
+	changes, err := diff(reviewed, commit)
+	return err == nil && versionBump(changes)
+}
+
+// versionBump checks if a diff made with git diff -U0 only bumps the version.
+// makepkg doesn't read the .SRCINFO, so any change to it is allowed
+func versionBump(diff string) bool {
+	file := ""
+	hunk := false
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			fields := strings.Fields(line)
+			file = strings.TrimPrefix(fields[len(fields)-1], "b/")
+			if file != "PKGBUILD" && file != ".SRCINFO" {
+				return false
+			}
+			hunk = false
+		case strings.HasPrefix(line, "@@"):
+			hunk = true
+		case !hunk:
+			// Headers of added, removed or renamed files change more than the version
+			if len(line) > 0 && !strings.HasPrefix(line, "index ") &&
+				!strings.HasPrefix(line, "--- ") && !strings.HasPrefix(line, "+++ ") {
+				return false
+			}
+		case file == ".SRCINFO", strings.HasPrefix(line, "\\"):
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, "-"):
+			if changed := strings.TrimSpace(line[1:]); len(changed) > 0 && !bumpLine.MatchString(changed) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// markReviewed records the checked out commit of a base as reviewed by the user
+func markReviewed(base string) {
+	commit, err := revParse(".", "HEAD")
+	if err != nil {
+		return
+	}
+	err = config.UpdateState(func(state *config.State) {
+		if state.Reviewed == nil {
+			state.Reviewed = map[string]string{}
+		}
+		state.Reviewed[base] = commit
+	})
+	if err != nil {
+		output.PrintErr("%s", err)
+	}
+}
+
+// installPrebuilt installs target from the packages of a base built by Prebuild for the checked out commit.
+// Other packages split from the base are only installed if they already are
+func installPrebuilt(base, target string) (bool, error) {
+	state, err := config.ReadState()
+	if err != nil {
+		return false, err
+	}
+	built, ok := state.Prebuilt[base]
+	if !ok {
+		return false, nil
+	}
+	if head, err := revParse(".", "HEAD"); err != nil || !usable(built, head, fileExists) {
+		return false, nil
+	}
+	files := installFiles(built.Files, target, func(name string) bool {
+		return exec.Command("pacman", "-Qq", name).Run() == nil
+	})
+	if len(files) == 0 {
+		return false, nil
+	}
+
+	output.Printf("Installing prebuilt \033[1m%s\033[0m %s", target, built.Version)
+	install := exec.Command("sudo", append([]string{"pacman", "-U"}, files...)...)
+	output.SetStd(install)
+	if err := install.Run(); err != nil {
+		return true, err
+	}
+
+	// The packages are in pacman's cache now
+	for _, file := range built.Files {
+		os.Remove(file)
+	}
+	return true, config.UpdateState(func(state *config.State) {
+		delete(state.Prebuilt, base)
+	})
+}
+
+// usable checks if packages built by Prebuild are of the checked out commit head and all still there
+func usable(built config.Prebuilt, head string, exists func(string) bool) bool {
+	if len(built.Files) == 0 || built.Commit != head {
+		return false
+	}
+	for _, file := range built.Files {
+		if !exists(file) {
+			return false
+		}
+	}
+	return true
+}
+
+// installFiles picks the package files of target and of the installed packages split from the same base
+func installFiles(files []string, target string, installed func(name string) bool) []string {
+	out := []string{}
+	for _, file := range files {
+		if name := packageName(file); name == target || installed(name) {
+			out = append(out, file)
+		}
+	}
+	return out
+}
+
+// packageName returns the name of the package in a file like foo-bar-1.0-1-x86_64.pkg.tar.zst
+func packageName(file string) string {
+	base := filepath.Base(file)
+	if i := strings.Index(base, ".pkg.tar"); i >= 0 {
+		base = base[:i]
+	}
+	// Drop the version, release and architecture
+	parts := strings.Split(base, "-")
+	if len(parts) <= 3 {
+		return base
+	}
+	return strings.Join(parts[:len(parts)-3], "-")
+}
+
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
+
+func revParse(dir, rev string) (string, error) {
+	git := exec.Command("git", "rev-parse", rev)
+	git.Dir = dir
+	out, err := git.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
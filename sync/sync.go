@@ -10,8 +10,9 @@ import (
 	"strings"
 
 	"github.com/Morganamilo/go-srcinfo"
+	"github.com/ericm/yup/index"
 	"github.com/ericm/yup/output"
-	"github.com/mikkeloscar/aur"
+	"github.com/ericm/yup/tui"
 
 	"fmt"
 
@@ -38,6 +39,7 @@ type PkgBuild struct {
 	optDepends  []string
 	update      bool
 	pacman      bool
+	rebuild     bool
 }
 
 type depPkg struct {
@@ -50,9 +52,25 @@ type depPkg struct {
 //
 // This checks each package param individually
 func Sync(packages []string, isAur bool, silent bool) error {
+	return syncPackages(packages, isAur, silent, false)
+}
+
+// Rebuild AUR packages even if the same version is already built or installed
+func Rebuild(packages []string) error {
+	return syncPackages(packages, true, false, true)
+}
+
+func syncPackages(packages []string, isAur bool, silent bool, rebuild bool) error {
 	if len(packages) > 0 && len(packages[0]) == 0 {
 		return fmt.Errorf("No targets specified (use -h for help)")
 	}
+	// If designated, install from pacman
+	if !isAur {
+		if errs := pacmanSync(packages, silent, false); len(errs) > 0 {
+			return errs[0]
+		}
+		return nil
+	}
 
 	// Create channels for goroutines
 	// Step 1: Check AUR
@@ -64,22 +82,16 @@ func Sync(packages []string, isAur bool, silent bool) error {
 	for _, p := range packages {
 		// Multithreaded downloads
 		go func(p string) {
-			// If designated, install from pacman
-			if !isAur {
-				buildChannel <- nil
-				errChannel <- nil
-				pacmanArgs = append(pacmanArgs, p)
-			}
-			repo, err := aur.Info([]string{p})
+			repo, err := index.Info([]string{p})
 			if err != nil {
 				errChannel <- err
+				buildChannel <- nil
 			} else {
 				if len(repo) > 0 {
 					aurDload("https://aur.archlinux.org/"+repo[0].PackageBase+".git", errChannel, buildChannel, repo[0].PackageBase, repo[0].Version, repo[0].Depends, repo[0].MakeDepends, repo[0].OptDepends)
 				} else {
 					errChannel <- output.Errorf("Didn't find an \033[1mAUR\033[0m package for \033[1m\033[32m%s\033[39m\033[0m, searching other repos\n", p)
-					buildChannel <- nil
-					pacmanArgs = append(pacmanArgs, p)
+					buildChannel <- &PkgBuild{name: p, pacman: true}
 				}
 			}
 
@@ -94,7 +106,11 @@ func Sync(packages []string, isAur bool, silent bool) error {
 				fmt.Print(err)
 			}
 		case pkg := <-buildChannel:
-			if pkg != nil {
+			if pkg != nil && pkg.pacman {
+				// Left for pacman once the AUR packages are done
+				pacmanArgs = append(pacmanArgs, pkg.name)
+			} else if pkg != nil {
+				pkg.rebuild = rebuild
 				// Install the package
 				if err := pkg.Install(silent, false); err != nil {
 					return err
@@ -105,18 +121,77 @@ func Sync(packages []string, isAur bool, silent bool) error {
 	}
 
 	// Now check pacman for unresolved args in pacmanArgs
-	if len(pacmanArgs) > 0 {
-		sync := pacmanSync(pacmanArgs, false, false)
+	found, missing := inRepos(pacmanArgs)
+	if len(found) > 0 {
+		sync := pacmanSync(found, false, false)
 		for _, s := range sync {
 			if s != nil {
 				return s
 			}
 		}
 	}
+	if len(missing) > 0 {
+		return &NotFoundError{Names: missing}
+	}
 
 	return nil
 }
 
+// NotFoundError is returned for targets that are neither in the AUR nor the repos
+type NotFoundError struct {
+	Names []string
+}
+
+func (err *NotFoundError) Error() string {
+	return fmt.Sprintf("Target not found: %s", strings.Join(err.Names, " "))
+}
+
+// inRepos splits names into those pacman can resolve, as packages, groups or provides, and the rest
+func inRepos(names []string) (found []string, missing []string) {
+	for _, name := range names {
+		if err := exec.Command("pacman", "-Sp", name).Run(); err != nil {
+			missing = append(missing, name)
+		} else {
+			found = append(found, name)
+		}
+	}
+	return found, missing
+}
+
+// SyncRepo installs repo packages in one pacman transaction, skipping those up to date
+func SyncRepo(packages []string) error {
+	output.Printf("Installing \033[1m\033[32m%s\033[39m\033[0m with \033[1mpacman\033[0m", strings.Join(packages, " "))
+	cmd := exec.Command("sudo", append([]string{"pacman", "-S", "--needed"}, packages...)...)
+	output.SetStd(cmd)
+	return cmd.Run()
+}
+
+// pickDeps shows dependencies in a checklist, all checked or not, and returns the checked ones
+func pickDeps(title string, deps []*depPkg, check bool) ([]*depPkg, error) {
+	packs := []output.Package{}
+	defaults := []bool{}
+	for _, dep := range deps {
+		repo := "aur"
+		if dep.pacman {
+			repo = "repo"
+		}
+		packs = append(packs, output.Package{Name: dep.name, Version: dep.version, Repo: repo, Aur: !dep.pacman})
+		defaults = append(defaults, check)
+	}
+
+	checked, ok := tui.Checklist(title, "Confirm", packs, nil, defaults)
+	if !ok {
+		return nil, errors.New("Installation cancelled")
+	}
+	picked := []*depPkg{}
+	for i, dep := range deps {
+		if checked[i] {
+			picked = append(picked, dep)
+		}
+	}
+	return picked, nil
+}
+
 // ParseNumbers filters according to user input
 func ParseNumbers(input string, packs *[]PkgBuild) {
 	inputs := strings.Split((strings.ToLower(strings.TrimSpace(input))), " ")
@@ -288,11 +363,10 @@ func ParseNumbersStr(input string, packs *[]string) {
 		}
 	}
 
-	newPacks := *packs
-
-	for i := range *packs {
-		if seen[i] {
-			newPacks = append(newPacks[:i], newPacks[i+1:]...)
+	newPacks := []string{}
+	for i, pack := range *packs {
+		if !seen[i] {
+			newPacks = append(newPacks, pack)
 		}
 	}
 
@@ -316,6 +390,8 @@ func (pkg *PkgBuild) Install(silent, isDep bool) error {
 	if !silent && !isDep {
 		// Print PkgBuild by default
 		conf := config.GetConfig().UserFile
+		// Whether the user saw the PKGBUILD or its diff
+		viewed := conf.PrintPkg
 		if conf.PrintPkg {
 			output.Printf("PKGBUILD:")
 			catPkg := exec.Command("cat", "PKGBUILD")
@@ -342,6 +418,7 @@ func (pkg *PkgBuild) Install(silent, isDep bool) error {
 				case "v":
 					// View
 					cmds = append(cmds, exec.Command("cat", "PKGBUILD"))
+					viewed = true
 					i++
 					goto Pkgbuild
 
@@ -356,6 +433,7 @@ func (pkg *PkgBuild) Install(silent, isDep bool) error {
 						diff = exec.Command("git", "diff", "@~..@")
 
 						cmds = append(cmds, diff)
+						viewed = true
 					}
 
 					i++
@@ -396,6 +474,11 @@ func (pkg *PkgBuild) Install(silent, isDep bool) error {
 				}
 			}
 		}
+
+		// The user has seen this commit, so yup --prebuild may build it
+		if viewed {
+			markReviewed(pkg.name)
+		}
 	}
 
 	// Make / Install the package
@@ -436,7 +519,11 @@ func (pkg *PkgBuild) Install(silent, isDep bool) error {
 				fmt.Printf("\033[1m%d\033[0m %s  ", i+1, dep.name)
 			}
 			fmt.Print("\n")
-			if !silent {
+			if !silent && tui.Enabled() {
+				if deps, err = pickDeps("Uncheck dependencies not to install", deps, true); err != nil {
+					return err
+				}
+			} else if !silent {
 				output.PrintIn("Numbers of packages not to install? (eg: 1 2 3, 1-3 or ^4)")
 				depRem, _ := scanner.ReadString('\n')
 
@@ -455,10 +542,16 @@ func (pkg *PkgBuild) Install(silent, isDep bool) error {
 
 			if !silent {
 				// Not to install
-				output.PrintIn("Numbers of packages not to install? (eg: 1 2 3, 1-3 or ^4)")
+				if tui.Enabled() {
+					if makeDeps, err = pickDeps("Uncheck make dependencies not to install", makeDeps, true); err != nil {
+						return err
+					}
+				} else {
+					output.PrintIn("Numbers of packages not to install? (eg: 1 2 3, 1-3 or ^4)")
 
-				depNum, _ := scanner.ReadString('\n')
-				ParseNumbersDep(depNum, &makeDeps)
+					depNum, _ := scanner.ReadString('\n')
+					ParseNumbersDep(depNum, &makeDeps)
+				}
 
 				output.PrintIn("Remove Make Dependencies after install? (y/N)")
 
@@ -477,7 +570,11 @@ func (pkg *PkgBuild) Install(silent, isDep bool) error {
 				fmt.Printf("\033[1m%d\033[0m %s  ", i+1, dep.name)
 			}
 			fmt.Print("\n")
-			if !silent {
+			if !silent && tui.Enabled() {
+				if optDeps, err = pickDeps("Check optional dependencies to install", optDeps, false); err != nil {
+					return err
+				}
+			} else if !silent {
 				output.PrintIn("Numbers of packages TO install? (eg: 1 2 3, 1-3 or ^4)")
 				depRem, _ := scanner.ReadString('\n')
 
@@ -633,8 +730,19 @@ func (pkg *PkgBuild) Install(silent, isDep bool) error {
 		}
 	}
 
+	// Use the packages built by yup --prebuild if there are any
+	if !pkg.rebuild {
+		if installed, err := installPrebuilt(info.Pkgbase, pkg.name); installed || err != nil {
+			return err
+		}
+	}
+
 	// Now, Install the actual package
-	cmdMake := exec.Command("makepkg", "-sic", "--noconfirm")
+	makeArgs := []string{"-sic", "--noconfirm"}
+	if pkg.rebuild {
+		makeArgs = append(makeArgs, "-f")
+	}
+	cmdMake := exec.Command("makepkg", makeArgs...)
 	// Pipe to stdout, etc
 	output.SetStd(cmdMake)
 	if err := cmdMake.Run(); err != nil {
@@ -656,12 +764,14 @@ func aurDload(url string, errChannel chan error, buildChannel chan *PkgBuild, na
 		git := exec.Command("git", "clone", url, dir)
 		if err := git.Run(); err != nil {
 			errChannel <- err
+			buildChannel <- nil
 			return
 		}
 	} else {
 		git := exec.Command("git", "fetch")
 		if err := git.Run(); err != nil {
 			errChannel <- err
+			buildChannel <- nil
 			return
 		}
 		update = true
@@ -669,7 +779,7 @@ func aurDload(url string, errChannel chan error, buildChannel chan *PkgBuild, na
 
 	// At the end, add dir path to buildChannel
 	defer func() {
-		buildChannel <- &PkgBuild{dir, conf.CacheDir, name, version, depends, makeDepends, optDepends, update, false}
+		buildChannel <- &PkgBuild{dir, conf.CacheDir, name, version, depends, makeDepends, optDepends, update, false, false}
 	}()
 
 	errChannel <- nil
@@ -795,7 +905,7 @@ func (pkg *PkgBuild) depCheck() ([]*depPkg, []*depPkg, []*depPkg, error) {
 
 	// Download func
 	dload := func(errChannel chan error, buildChannel chan *PkgBuild, dep string) {
-		repo, err := aur.Info([]string{dep})
+		repo, err := index.Info([]string{dep})
 		if err != nil {
 			output.PrintErr("Dependencies error: %s", err)
 		}
@@ -838,6 +948,10 @@ func (pkg *PkgBuild) depCheck() ([]*depPkg, []*depPkg, []*depPkg, error) {
 	for _i := 0; _i < len(depNames)*2; _i++ {
 		select {
 		case pkg := <-buildChannel:
+			if pkg == nil {
+				// Failed to download
+				continue
+			}
 			dep := &depPkg{PkgBuild: *pkg}
 			out = append([]*depPkg{dep}, out...)
 			// Map dependency tree
@@ -855,6 +969,10 @@ func (pkg *PkgBuild) depCheck() ([]*depPkg, []*depPkg, []*depPkg, error) {
 	for _i := 0; _i < len(makeDepNames)*2; _i++ {
 		select {
 		case pkg := <-buildChannelM:
+			if pkg == nil {
+				// Failed to download
+				continue
+			}
 			dep := &depPkg{PkgBuild: *pkg}
 			outMake = append([]*depPkg{dep}, outMake...)
 			// Map dependency tree
@@ -872,6 +990,10 @@ func (pkg *PkgBuild) depCheck() ([]*depPkg, []*depPkg, []*depPkg, error) {
 	for _i := 0; _i < len(optDepNames)*2; _i++ {
 		select {
 		case pkg := <-buildChannelO:
+			if pkg == nil {
+				// Failed to download
+				continue
+			}
 			dep := &depPkg{PkgBuild: *pkg}
 			outOpts = append(outOpts, dep)
 			// Map dependency tree
@@ -1 +1,20 @@
 package sync
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCascade(t *testing.T) {
+	requiredBy := map[string][]string{
+		"qt5-base":        {"qt5-svg", "kdeconnect"},
+		"qt5-svg":         {"kdeconnect"},
+		"kdeconnect":      {},
+		"qt5-declarative": {"kdeconnect"},
+	}
+	got := cascade([]string{"qt5-base", "qt5-declarative"}, func(name string) []string { return requiredBy[name] })
+	want := []string{"qt5-base", "qt5-declarative", "qt5-svg", "kdeconnect"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/ericm/yup/output"
+)
+
+// filterView returns the numbers of the packages whose name or description contains filter,
+// in the order they are listed. Numbers count up from the bottom, so they don't change while filtering
+func filterView(packs []output.Package, filter string) []int {
+	filter = strings.ToLower(filter)
+	view := []int{}
+	for i, pack := range packs {
+		if len(filter) == 0 ||
+			strings.Contains(strings.ToLower(pack.Name), filter) ||
+			strings.Contains(strings.ToLower(pack.Description), filter) {
+			view = append(view, len(packs)-i)
+		}
+	}
+	return view
+}
+
+// viewPos returns the row of num in the view, counting from 1 at the bottom, or 0 if it's hidden
+func viewPos(view []int, num int) int {
+	for i, n := range view {
+		if n == num {
+			return len(view) - i
+		}
+	}
+	return 0
+}
+
+// step moves delta rows up the view from selected, stopping at either end.
+// wrap goes round to the other end instead, for jumping between matches
+func step(view []int, selected, delta int, wrap bool) int {
+	if len(view) == 0 {
+		return selected
+	}
+	pos := viewPos(view, selected) + delta
+	switch {
+	case wrap:
+		pos = ((pos-1)%len(view)+len(view))%len(view) + 1
+	case pos < 1:
+		pos = 1
+	case pos > len(view):
+		pos = len(view)
+	}
+	return view[len(view)-pos]
+}
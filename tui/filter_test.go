@@ -0,0 +1,44 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ericm/yup/output"
+)
+
+func TestFilterView(t *testing.T) {
+	packs := []output.Package{
+		{Name: "neovim", Description: "Fork of Vim"},
+		{Name: "vim", Description: "Vi Improved"},
+		{Name: "emacs", Description: "The extensible editor"},
+		{Name: "gvim", Description: "Vi Improved, with a GUI"},
+	}
+	if view := filterView(packs, ""); !reflect.DeepEqual(view, []int{4, 3, 2, 1}) {
+		t.Errorf("empty filter: got %v", view)
+	}
+	view := filterView(packs, "VIM")
+	if !reflect.DeepEqual(view, []int{4, 3, 1}) {
+		t.Fatalf("vim: got %v", view)
+	}
+
+	tests := []struct {
+		selected, delta int
+		wrap            bool
+		want            int
+	}{
+		{1, 1, false, 3},
+		{3, 1, false, 4},
+		{4, 1, false, 4},
+		{4, 1, true, 1},
+		{1, -1, true, 4},
+		{1, -5, false, 1},
+		// Hidden packages move to the bottom of the view
+		{2, 0, false, 1},
+	}
+	for _, test := range tests {
+		if got := step(view, test.selected, test.delta, test.wrap); got != test.want {
+			t.Errorf("step(%d, %d, %v): got %d, want %d", test.selected, test.delta, test.wrap, got, test.want)
+		}
+	}
+}
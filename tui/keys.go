@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ericm/goncurses"
+	"github.com/ericm/yup/config"
+)
+
+// Actions shown in the help box, in order
+var helpActions = []Action{
+	{"toggle", "Select"},
+	{"install", "Install"},
+	{"remove", "Remove"},
+	{"upstream", "Upstream"},
+	{"details", "Details"},
+	{"filter", "Filter"},
+	{"preview", "Preview"},
+	{"quit", "Quit"},
+}
+
+// bindings returns the configured keybindings, or the defaults if the config wasn't read
+func bindings() map[string][]string {
+	conf := config.GetConfig().UserFile
+	if conf.Keybindings == nil {
+		return config.DefaultKeybindings()
+	}
+	return conf.Keybindings
+}
+
+// keymap returns the action of each bound key
+func keymap() map[goncurses.Key]string {
+	keys := map[goncurses.Key]string{}
+	for action, names := range bindings() {
+		for _, name := range names {
+			// Validated when the config was read
+			if code, err := config.ParseKey(name); err == nil {
+				keys[goncurses.Key(code)] = action
+			}
+		}
+	}
+
+	if config.GetConfig().UserFile.VimKeybindings {
+		if _, ok := keys['k']; !ok {
+			keys['k'] = "up"
+		}
+		if _, ok := keys['j']; !ok {
+			keys['j'] = "down"
+		}
+	}
+	return keys
+}
+
+// help returns the actions of the list shown in the help box.
+// Remove is left out of lists that can't remove
+func (list *List) help() []Action {
+	actions := []Action{}
+	for _, action := range helpActions {
+		if action.Name == "remove" && !list.Remove {
+			continue
+		}
+		if action.Name == "install" && len(list.Confirm) > 0 {
+			action.Label = list.Confirm
+		}
+		if action.Name == "quit" {
+			actions = append(actions, list.Extra...)
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// helpLines returns the lines of the help box, like "I/Z: Install"
+func helpLines(bindings map[string][]string, actions []Action) []string {
+	lines := []string{}
+	for _, help := range actions {
+		names := []string{}
+		for _, name := range bindings[help.Name] {
+			names = append(names, keyLabel(name))
+		}
+		if len(names) == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", strings.Join(names, "/"), help.Label))
+	}
+	return lines
+}
+
+// keyLabel capitalises a key name for the help box
+func keyLabel(name string) string {
+	if len(name) == 1 {
+		return strings.ToUpper(name)
+	}
+	return strings.ToUpper(name[:1]) + strings.ToLower(name[1:])
+}
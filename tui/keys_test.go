@@ -0,0 +1,26 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHelpLines(t *testing.T) {
+	bindings := map[string][]string{
+		"toggle":  {"enter", "space"},
+		"install": {"i", "z"},
+		"remove":  {"r"},
+		"quit":    {"q"},
+		"up":      {"k"},
+	}
+	want := []string{"Enter/Space: Select", "I/Z: Install", "Q: Quit"}
+	if got := helpLines(bindings, (&List{}).help()); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// Checklists confirm with the install keys
+	want = []string{"Enter/Space: Select", "I/Z: Remove", "Q: Quit"}
+	if got := helpLines(bindings, (&List{Confirm: "Remove"}).help()); !reflect.DeepEqual(got, want) {
+		t.Errorf("checklist: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,467 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/ericm/goncurses"
+	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/output"
+)
+
+// List is a checklist of packages drawn with ncurses.
+// Packages are numbered from the bottom, and Checked is keyed by those numbers
+type List struct {
+	// Prompt shown in the bottom bar, like "Or type packages to install"
+	Title    string
+	Packages []output.Package
+	Checked  map[int]bool
+	// Extra info shown after each package's version, like a version change
+	Info []string
+	// Label of the install action in the help box, Install if empty
+	Confirm string
+	// Allow the remove action
+	Remove bool
+	// Get the packages removed along with the selection, to confirm removing them, optional
+	Cascade func(packs []output.Package) ([]output.Package, error)
+	// Return no packages when none are checked, rather than the highlighted one
+	AllowNone bool
+	// Fill in a package before showing its details, optional
+	Fill func(pack *output.Package)
+	// Get the PKGBUILD shown in the preview pane, optional
+	Pkgbuild func(pack output.Package) (string, error)
+	// More actions that close the list with the selection, like install
+	Extra []Action
+	// Filter typed after /, kept between runs
+	Filter string
+}
+
+// Action is an extra action of a list, bound to keys in the config
+type Action struct {
+	Name  string
+	Label string
+}
+
+// Enabled checks if lists should be drawn with ncurses rather than asking for numbers
+func Enabled() bool {
+	conf := config.GetConfig()
+	return conf.UserFile.Ncurses && !conf.Ncurses && !conf.JSON
+}
+
+// Checklist asks which packages to keep checked, starting with those in defaults.
+// confirm labels the install action, which closes it.
+// It returns whether each package is checked, or false if the user quit
+func Checklist(title, confirm string, packs []output.Package, info []string, defaults []bool) ([]bool, bool) {
+	list := &List{Title: title, Confirm: confirm, Packages: packs, Info: info, Checked: map[int]bool{}, AllowNone: true}
+	for i, check := range defaults {
+		list.Checked[len(packs)-i] = check
+	}
+	if _, action := list.Run(); action == "quit" {
+		return nil, false
+	}
+
+	checked := make([]bool, len(packs))
+	for i := range packs {
+		checked[i] = list.Checked[len(packs)-i]
+	}
+	return checked, true
+}
+
+// Run shows the list until the user installs, removes or quits.
+// It returns the checked packages, or the highlighted one if none are,
+// and the action that closed the list: "install", "remove" or "quit"
+func (list *List) Run() ([]output.Package, string) {
+	if list.Checked == nil {
+		list.Checked = map[int]bool{}
+	}
+	stdscr, err := start()
+	if err != nil {
+		output.PrintErr("%s", err)
+		return nil, "quit"
+	}
+	defer goncurses.End()
+
+	resized := make(chan os.Signal, 1)
+	signal.Notify(resized, syscall.SIGWINCH)
+	defer signal.Stop(resized)
+	// Stop waiting for keys now and then to check for resizes
+	stdscr.Timeout(100)
+
+	s := newScreen(list, stdscr)
+	keys := keymap()
+	for {
+		s.draw()
+		ch := stdscr.GetChar()
+
+		select {
+		case <-resized:
+			s.resize()
+		case res := <-s.loaded:
+			s.showLoaded(res)
+		default:
+		}
+		s.load()
+
+		action := keys[ch]
+		switch {
+		case ch == 0:
+			continue
+		case ch == goncurses.KEY_RESIZE:
+			s.resize()
+			continue
+		case s.filtering:
+			// Keys go to the filter prompt until enter or escape
+			s.filterKey(ch)
+			continue
+		case ch == goncurses.KEY_MOUSE:
+			action = s.mouse()
+		}
+
+		if done, ok := s.handle(ch, action); ok {
+			// Fill isn't left running once the caller has the packages back
+			s.await()
+			list.Filter = s.filter
+			if done == "quit" {
+				return nil, done
+			}
+			return list.selection(s.view, s.selected), done
+		}
+	}
+}
+
+// selection returns the checked packages, or the highlighted one if none are
+func (list *List) selection(view []int, selected int) []output.Package {
+	packs := []output.Package{}
+	for i, pack := range list.Packages {
+		if list.Checked[len(list.Packages)-i] {
+			packs = append(packs, pack)
+		}
+	}
+	if len(packs) == 0 && !list.AllowNone && viewPos(view, selected) != 0 {
+		packs = append(packs, list.Packages[len(list.Packages)-selected])
+	}
+	return packs
+}
+
+// extra checks if action is one of the list's extra actions
+func (list *List) extra(action string) bool {
+	for _, extra := range list.Extra {
+		if extra.Name == action {
+			return true
+		}
+	}
+	return false
+}
+
+// packRows returns the rows each package takes on a screen cols wide.
+// Narrow screens leave out the line under each package
+func packRows(cols int) int {
+	if cols < output.CompactWidth {
+		return 1
+	}
+	return 2
+}
+
+// getactive returns the number of the package shown at row y, or -1
+func getactive(y, my, offset int, view []int, per int) int {
+	if y >= my-3 {
+		return -1
+	}
+
+	for i, num := range view {
+		iy := my - (per * (len(view) - i)) - 3 + offset
+
+		if y >= iy && y < iy+per {
+			return num
+		}
+
+	}
+	return -1
+}
+
+// row prints parts of a line from left to right, cutting them at the edge of the screen
+type row struct {
+	win   *goncurses.Window
+	y, x  int
+	width int
+}
+
+func (r *row) print(text string) {
+	if r.x >= r.width {
+		return
+	}
+	text = output.Truncate(text, r.width-r.x, "")
+	r.win.MovePrint(r.y, r.x, text)
+	r.x += output.Width(text)
+}
+
+func printBar(stdscr *goncurses.Window, title string, newSel, toSel int, notSel bool, filter string, filtering bool) int {
+	my, mx := stdscr.MaxYX()
+	for y := my - 3; y < my; y++ {
+		stdscr.Move(y, 0)
+		stdscr.ClearToEOL()
+	}
+
+	// Print line
+	stdscr.ColorOn(8)
+	stdscr.MovePrint(my-3, 0, strings.Repeat("=", mx))
+	stdscr.ColorOff(8)
+
+	// Print Input
+	stdscr.ColorOn(5)
+	stdscr.MovePrint(my-2, 0, "==>")
+	stdscr.ColorOff(5)
+	status := &row{win: stdscr, y: my - 2, x: 4, width: mx}
+	if len(filter) > 0 {
+		status.print(fmt.Sprintf("Showing packages matching /%s (n/N to jump, esc to clear)", filter))
+	} else {
+		status.print("Click on a package above, use the arrow keys and enter")
+	}
+
+	// Leave room for what's typed after the prompt
+	input := &row{win: stdscr, y: my - 1, width: mx}
+	if filtering {
+		// Filter prompt
+		prompt := "==> Filter by name or description (enter to keep, esc to clear):"
+		if output.Width(prompt)+20 > mx {
+			prompt = "==> Filter:"
+		}
+		stdscr.ColorOn(3)
+		input.print(prompt)
+		stdscr.ColorOff(3)
+		input.x++
+		input.print(filter)
+		stdscr.AttrOn(goncurses.A_REVERSE)
+		input.print(" ")
+		stdscr.AttrOff(goncurses.A_REVERSE)
+		return my
+	}
+
+	prompt := fmt.Sprintf("==> %s (eg: 1 2 3, 1-3 or ^4):", title)
+	if output.Width(prompt)+10 > mx {
+		prompt = fmt.Sprintf("==> %s:", title)
+	}
+	stdscr.ColorOn(4)
+	input.print(prompt)
+	stdscr.ColorOff(4)
+	input.x++
+
+	// Print user input
+	if notSel {
+		input.print("^")
+	}
+	if toSel != 0 {
+		input.print(strconv.Itoa(toSel) + "-")
+	}
+	if newSel != 0 {
+		input.print(strconv.Itoa(newSel))
+	}
+
+	return my
+}
+
+func (list *List) printPacks(stdscr *goncurses.Window, view []int, selected int) int {
+	packs, checked := &list.Packages, list.Checked
+	my, mx := stdscr.MaxYX()
+	per := packRows(mx)
+	// Calculate offset up
+	offset := 0
+	if pos := viewPos(view, selected); pos*per > my-5 {
+		offset = pos*per - my + 5
+	}
+	// Clear the rows above the bar, which is drawn on its own
+	for y := 0; y < my-3; y++ {
+		stdscr.Move(y, 0)
+		stdscr.ClearToEOL()
+	}
+	for i, ind := range view {
+		y := my - (per * (len(view) - i)) - 3 + offset
+		// Skip packages off the screen, the description of one may still show at the top
+		if y < 1-per || y > my-4 {
+			continue
+		}
+		item := (*packs)[len(*packs)-ind]
+		sel := ind == selected
+		check := checked[ind]
+		line := &row{win: stdscr, y: y, width: mx}
+
+		// Number
+		if sel {
+			stdscr.ColorOn(7)
+		} else if check {
+			stdscr.ColorOn(9)
+		}
+		stdscr.AttrOn(goncurses.A_BOLD)
+		if check {
+			line.print(fmt.Sprintf("%-5s", fmt.Sprintf("(%d)", ind)))
+		} else {
+			line.print(fmt.Sprintf("(%d)", ind))
+		}
+		stdscr.AttrOff(goncurses.A_BOLD)
+		if sel {
+			stdscr.ColorOff(7)
+		} else if check {
+			stdscr.ColorOff(9)
+		}
+		line.x = 5
+
+		// Repo
+		pair := themePair(output.CurrentTheme().Repo(item.Repo))
+		if check {
+			stdscr.ColorOn(9)
+		} else {
+			stdscr.ColorOn(pair)
+		}
+		line.print(item.Repo)
+		if check {
+			stdscr.ColorOff(9)
+		} else {
+			stdscr.ColorOff(pair)
+		}
+
+		// Slash
+		if check {
+			stdscr.ColorOn(9)
+		}
+		stdscr.AttrOn(goncurses.A_DIM)
+		line.print("/")
+		stdscr.AttrOff(goncurses.A_DIM)
+		if check {
+			stdscr.ColorOff(9)
+		}
+
+		if check {
+			stdscr.ColorOn(9)
+		}
+		// Name
+		stdscr.AttrOn(goncurses.A_BOLD)
+		line.print(item.Name)
+		stdscr.AttrOff(goncurses.A_BOLD)
+		line.x++
+
+		if check {
+			stdscr.ColorOff(9)
+		}
+
+		// Version
+		line.print(item.Version)
+		line.x++
+
+		// Extra info, like a version change
+		if n := len(*packs) - ind; n < len(list.Info) && len(list.Info[n]) > 0 {
+			stdscr.AttrOn(goncurses.A_DIM)
+			line.print(list.Info[n])
+			stdscr.AttrOff(goncurses.A_DIM)
+			line.x++
+		}
+
+		// Installed
+		if item.Installed {
+			line.print("(")
+			installed := themePair(output.CurrentTheme().Installed)
+			stdscr.AttrOn(goncurses.A_BOLD)
+			stdscr.ColorOn(installed)
+			line.print("INSTALLED")
+			stdscr.ColorOff(installed)
+			if item.InstalledVersion != item.Version {
+				// Outdated
+				outdated := themePair(output.CurrentTheme().Outdated)
+				line.x++
+				stdscr.ColorOn(outdated)
+				line.print("OUTDATED")
+				stdscr.ColorOff(outdated)
+				stdscr.AttrOff(goncurses.A_BOLD)
+				line.x++
+				line.print(item.InstalledVersion)
+			}
+			stdscr.AttrOff(goncurses.A_BOLD)
+			line.print(")")
+			// Size
+			line.x++
+			line.print(fmt.Sprintf("Install Size: %s", item.InstalledSize))
+			line.x++
+		} else if item.Size > 0 {
+			line.print(fmt.Sprintf("Size: %s", output.FormatSize(item.Size)))
+			line.x++
+		}
+
+		// Out of date
+		if item.OutOfDate != 0 {
+			stdscr.AttrOn(goncurses.A_BOLD)
+			stdscr.ColorOn(1)
+			line.print("(OUT OF DATE)")
+			stdscr.ColorOff(1)
+			stdscr.AttrOff(goncurses.A_BOLD)
+			line.x++
+		}
+
+		// Description, after the rest on narrow screens
+		if per == 1 {
+			stdscr.AttrOn(goncurses.A_DIM)
+			line.print(output.Truncate("- "+item.Description, mx-line.x, ".."))
+			stdscr.AttrOff(goncurses.A_DIM)
+			continue
+		}
+		desc := &row{win: stdscr, y: y + 1, x: 5, width: mx}
+		desc.print(output.Truncate("- "+item.Description, mx-5, ".."))
+	}
+
+	return offset
+}
+
+// Help, generated from the keybindings. Returns the rows it takes
+func printhelp(stdscr *goncurses.Window, actions []Action) int {
+	_, mx := stdscr.MaxYX()
+	// No room for it next to the list on narrow screens
+	if mx < output.CompactWidth {
+		return 0
+	}
+	lines := helpLines(bindings(), actions)
+	width := 0
+	for _, line := range lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+
+	stdscr.ColorOn(10)
+	for i, line := range lines {
+		stdscr.MovePrintf(i, mx-width-2, " %-*s ", width, line)
+	}
+	stdscr.ColorOff(10)
+	return len(lines)
+}
+
+// printDetails shows every field of a package in a popup until a key is pressed
+func printDetails(stdscr *goncurses.Window, pack output.Package) {
+	my, mx := stdscr.MaxYX()
+	fields := output.InfoFields(pack)
+
+	h, w := len(fields)+2, mx-4
+	if h > my-2 {
+		h = my - 2
+	}
+	win, err := goncurses.NewWindow(h, w, (my-h)/2, 2)
+	if err != nil {
+		return
+	}
+	defer win.Delete()
+	win.Box(0, 0)
+
+	for i, field := range fields {
+		if i+1 >= h-1 {
+			break
+		}
+		win.AttrOn(goncurses.A_BOLD)
+		win.MovePrintf(i+1, 2, "%-16s:", field.Name)
+		win.AttrOff(goncurses.A_BOLD)
+
+		win.MovePrint(i+1, 20, output.Truncate(field.Value, w-22, "..."))
+	}
+	win.Refresh()
+	win.GetChar()
+}
@@ -0,0 +1,14 @@
+package tui
+
+// #include <locale.h>
+// #include <stdlib.h>
+import "C"
+import "unsafe"
+
+// setLocale sets the locale from the environment, without which ncurses
+// draws multibyte characters as escapes
+func setLocale() {
+	empty := C.CString("")
+	defer C.free(unsafe.Pointer(empty))
+	C.setlocale(C.LC_ALL, empty)
+}
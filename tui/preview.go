@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ericm/goncurses"
+	"github.com/ericm/yup/output"
+)
+
+// previewLines lays out the preview of a package for a pane width columns wide
+func previewLines(pack output.Package, pkgbuild string, width int) []string {
+	lines := []string{pack.Name + " " + pack.Version, ""}
+	lines = append(lines, output.Wrap(pack.Description, width)...)
+	lines = append(lines, "")
+	for _, field := range output.InfoFields(pack) {
+		switch field.Name {
+		case "Name", "Version", "Description":
+			continue
+		}
+		lines = append(lines, output.Wrap(fmt.Sprintf("%s: %s", field.Name, field.Value), width)...)
+	}
+
+	if len(pkgbuild) > 0 {
+		lines = append(lines, "", "PKGBUILD:")
+		for _, line := range strings.Split(strings.TrimRight(pkgbuild, "\n"), "\n") {
+			lines = append(lines, output.Wrap(strings.Replace(line, "\t", "    ", -1), width)...)
+		}
+	}
+	return lines
+}
+
+// printPreview draws the preview pane on the right from row top, under the help box,
+// starting at line scroll. It returns scroll kept within the lines
+func printPreview(stdscr *goncurses.Window, lines []string, scroll, top int) int {
+	my, mx := stdscr.MaxYX()
+	w := mx / 2
+	h := my - 3 - top
+	if h < 5 || w < 20 {
+		return scroll
+	}
+	win, err := goncurses.NewWindow(h, w, top, mx-w)
+	if err != nil {
+		return scroll
+	}
+	defer win.Delete()
+
+	rows := h - 2
+	if scroll > len(lines)-rows {
+		scroll = len(lines) - rows
+	}
+	if scroll < 0 {
+		scroll = 0
+	}
+
+	win.Box(0, 0)
+	for i := 0; i < rows && scroll+i < len(lines); i++ {
+		if i == 0 && scroll == 0 {
+			win.AttrOn(goncurses.A_BOLD)
+			win.MovePrint(i+1, 2, lines[i])
+			win.AttrOff(goncurses.A_BOLD)
+			continue
+		}
+		win.MovePrint(i+1, 2, lines[scroll+i])
+	}
+	if len(lines) > rows {
+		win.MovePrintf(h-1, w-12, " %d/%d ", scroll+1, len(lines)-rows+1)
+	}
+	// Updated on the terminal with the rest of the screen
+	win.NoutRefresh()
+	return scroll
+}
+
+// previewResult is a package filled in and its PKGBUILD, fetched for the preview pane
+type previewResult struct {
+	num  int
+	pack output.Package
+	// nil if it wasn't asked for
+	pkgbuild *string
+}
+
+// previewText returns the preview of the highlighted package with what has been fetched so far
+func (s *screen) previewText(width int) []string {
+	pack := s.list.Packages[len(s.list.Packages)-s.previewOf]
+	pkgbuild := ""
+	if s.showPkgbuild {
+		pkgbuild = s.pkgbuilds[s.previewOf]
+	}
+	lines := previewLines(pack, pkgbuild, width)
+	if s.loading() {
+		lines = append(lines, "", "loading…")
+	}
+	return lines
+}
+
+// loading checks if the preview is waiting for anything to be fetched
+func (s *screen) loading() bool {
+	if viewPos(s.view, s.previewOf) == 0 {
+		return false
+	}
+	_, fetched := s.pkgbuilds[s.previewOf]
+	return (s.list.Fill != nil && !s.filled[s.previewOf]) ||
+		(s.showPkgbuild && s.list.Pkgbuild != nil && !fetched)
+}
+
+// load fetches what the preview is waiting for in the background,
+// once the highlight has stayed on a package for previewDelay
+func (s *screen) load() {
+	if !s.preview || s.fetching || !s.loading() || time.Since(s.moved) < previewDelay {
+		return
+	}
+	s.fetching = true
+	num := s.previewOf
+	pack := s.list.Packages[len(s.list.Packages)-num]
+	fill := s.list.Fill
+	if s.filled[num] {
+		fill = nil
+	}
+	var pkgbuild func(output.Package) (string, error)
+	if _, fetched := s.pkgbuilds[num]; s.showPkgbuild && !fetched {
+		pkgbuild = s.list.Pkgbuild
+	}
+
+	go func() {
+		res := previewResult{num: num}
+		if fill != nil {
+			fill(&pack)
+		}
+		res.pack = pack
+		if pkgbuild != nil {
+			text, err := pkgbuild(pack)
+			if err != nil {
+				text = err.Error()
+			}
+			res.pkgbuild = &text
+		}
+		s.loaded <- res
+	}()
+}
+
+// showLoaded keeps what load fetched and shows it if the package is still highlighted
+func (s *screen) showLoaded(res previewResult) {
+	s.fetching = false
+	s.list.Packages[len(s.list.Packages)-res.num] = res.pack
+	s.filled[res.num] = true
+	if res.pkgbuild != nil {
+		s.pkgbuilds[res.num] = *res.pkgbuild
+	}
+	if res.num == s.previewOf {
+		s.previewLines = nil
+		s.dirty |= drawPreview
+	}
+}
+
+// await waits for a fetch started by load, so nothing else runs alongside it
+func (s *screen) await() {
+	if s.fetching {
+		s.showLoaded(<-s.loaded)
+	}
+}
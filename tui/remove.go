@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/ericm/goncurses"
+	"github.com/ericm/yup/output"
+)
+
+// confirmRemove shows what removing the selection takes with it, and asks to go ahead
+func (s *screen) confirmRemove(selection []output.Package) bool {
+	// Draw over the popup once it's closed
+	defer func() {
+		s.stdscr.Touch()
+		s.dirty = drawAll
+	}()
+
+	packs, err := s.list.Cascade(selection)
+	switch {
+	case err != nil:
+		popup(s.stdscr, "Couldn't find what requires them", []string{err.Error()}, "Press any key to go back")
+		return false
+	case len(packs) == 0:
+		popup(s.stdscr, "Nothing to remove", []string{"None of the selected packages are installed"}, "Press any key to go back")
+		return false
+	}
+	title, lines := removeLines(selection, packs)
+	ch := popup(s.stdscr, title, lines, "Press y to remove them, any other key to cancel")
+	return ch == 'y' || ch == 'Y'
+}
+
+// removeLines returns the title and lines of the removal popup.
+// packs are the installed packages removed, those selected and those requiring them
+func removeLines(selection, packs []output.Package) (string, []string) {
+	selected := map[string]bool{}
+	for _, pack := range selection {
+		selected[pack.Name] = true
+	}
+
+	var freed int64
+	lines, required := []string{}, []string{}
+	for _, pack := range packs {
+		freed += int64(pack.InstalledSizeInt)
+		line := fmt.Sprintf("%s %s (%s)", pack.Name, pack.Version, output.FormatSize(int64(pack.InstalledSizeInt)))
+		if selected[pack.Name] {
+			lines = append(lines, line)
+		} else {
+			required = append(required, "  "+line)
+		}
+	}
+	if len(required) > 0 {
+		lines = append(lines, "", "Also removed, as they require them:")
+		lines = append(lines, required...)
+	}
+	return fmt.Sprintf("Remove %d package(s), freeing %s?", len(packs), output.FormatSize(freed)), lines
+}
+
+// popup shows lines in a box in the middle of the screen and returns the key pressed to close it
+func popup(stdscr *goncurses.Window, title string, lines []string, footer string) goncurses.Key {
+	my, mx := stdscr.MaxYX()
+	h, w := len(lines)+6, mx-4
+	if h > my-2 {
+		h = my - 2
+	}
+	win, err := goncurses.NewWindow(h, w, (my-h)/2, 2)
+	if err != nil {
+		return 0
+	}
+	defer win.Delete()
+	win.Box(0, 0)
+
+	win.AttrOn(goncurses.A_BOLD)
+	win.MovePrint(1, 2, output.Truncate(title, w-4, ".."))
+	win.AttrOff(goncurses.A_BOLD)
+
+	// Leave room for the footer, and say how many lines don't fit
+	rows := h - 6
+	if len(lines) > rows && rows > 0 {
+		lines = append(lines[:rows-1:rows-1], fmt.Sprintf("and %d more", len(lines)-rows+1))
+	}
+	for i, line := range lines {
+		if i >= rows {
+			break
+		}
+		win.MovePrint(i+3, 2, output.Truncate(line, w-4, ".."))
+	}
+
+	win.ColorOn(4)
+	win.MovePrint(h-2, 2, output.Truncate(footer, w-4, ""))
+	win.ColorOff(4)
+	win.Refresh()
+	return win.GetChar()
+}
@@ -0,0 +1,24 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ericm/yup/output"
+)
+
+func TestRemoveLines(t *testing.T) {
+	selection := []output.Package{{Name: "qt5-base"}, {Name: "not-installed"}}
+	packs := []output.Package{
+		{Name: "qt5-base", Version: "5.15", InstalledSizeInt: 2048},
+		{Name: "qt5-svg", Version: "5.15", InstalledSizeInt: 1536},
+	}
+	title, lines := removeLines(selection, packs)
+	if title != "Remove 2 package(s), freeing 3.50 KiB?" {
+		t.Errorf("title: got %q", title)
+	}
+	want := []string{"qt5-base 5.15 (2.00 KiB)", "", "Also removed, as they require them:", "  qt5-svg 5.15 (1.50 KiB)"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines: got %q, want %q", lines, want)
+	}
+}
@@ -0,0 +1,393 @@
+package tui
+
+import (
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/ericm/goncurses"
+	"github.com/ericm/yup/output"
+)
+
+// region is a part of the screen that has to be drawn again
+type region int
+
+const (
+	drawPacks region = 1 << iota
+	drawBar
+	drawHelp
+	drawPreview
+
+	drawAll = drawPacks | drawBar | drawHelp | drawPreview
+)
+
+// Mouse events closer together than this are dropped, so the wheel doesn't flood the list
+const mouseInterval = 30 * time.Millisecond
+
+// How long the highlight has to stay on a package before its preview is fetched
+const previewDelay = 250 * time.Millisecond
+
+// screen is the state of a list while it's shown
+type screen struct {
+	list   *List
+	stdscr *goncurses.Window
+	// Size of the terminal, kept up to date on resizes
+	rows, cols int
+
+	view     []int
+	selected int
+	offset   int
+
+	// The filter typed after /
+	filter    string
+	filtering bool
+
+	// Numbers typed so far, like 1-3 or ^4
+	newSel int
+	toSel  int
+	notSel bool
+
+	preview       bool
+	showPkgbuild  bool
+	previewOf     int
+	previewScroll int
+	// Laid out preview of previewOf, nil when it has to be laid out again
+	previewLines []string
+	helpRows     int
+
+	// When previewOf was highlighted
+	moved time.Time
+	// Packages filled in and PKGBUILDs fetched for the preview, by number
+	filled    map[int]bool
+	pkgbuilds map[int]string
+	fetching  bool
+	loaded    chan previewResult
+
+	dirty     region
+	lastMouse time.Time
+}
+
+func newScreen(list *List, stdscr *goncurses.Window) *screen {
+	s := &screen{list: list, stdscr: stdscr, selected: 1, filter: list.Filter, dirty: drawAll,
+		filled: map[int]bool{}, pkgbuilds: map[int]string{}, loaded: make(chan previewResult, 1)}
+	if stdscr != nil {
+		s.rows, s.cols = stdscr.MaxYX()
+	}
+	s.view = filterView(list.Packages, s.filter)
+	return s
+}
+
+// start initialises ncurses and the colours used by lists
+func start() (*goncurses.Window, error) {
+	setLocale()
+	stdscr, err := goncurses.Init()
+	if err != nil {
+		return nil, err
+	}
+	stdscr.Keypad(true)
+
+	goncurses.Cursor(0)
+	goncurses.Echo(false)
+	goncurses.Raw(true)
+
+	goncurses.MouseMask(goncurses.M_ALL, nil) // temporarily enable all mouse clicks
+	// Init the ncurses colours
+	goncurses.StartColor()
+	goncurses.UseDefaultColors()
+	goncurses.InitPair(1, goncurses.C_RED, -1)
+	goncurses.InitPair(11, goncurses.C_RED, goncurses.C_WHITE)
+	goncurses.InitPair(2, goncurses.C_CYAN, -1)
+	goncurses.InitPair(12, goncurses.C_CYAN, goncurses.C_WHITE)
+	goncurses.InitPair(3, goncurses.C_YELLOW, -1)
+	goncurses.InitPair(13, goncurses.C_YELLOW, goncurses.C_WHITE)
+	goncurses.InitPair(4, goncurses.C_GREEN, -1)
+	goncurses.InitPair(14, goncurses.C_GREEN, goncurses.C_WHITE)
+	goncurses.InitPair(5, goncurses.C_MAGENTA, -1)
+	goncurses.InitPair(15, goncurses.C_MAGENTA, goncurses.C_WHITE)
+	goncurses.InitPair(6, goncurses.C_WHITE, -1)
+	goncurses.InitPair(16, goncurses.C_WHITE, goncurses.C_WHITE)
+	// Selected and checked rows, from the theme
+	themePairs = map[string]int16{}
+	theme := output.CurrentTheme()
+	goncurses.InitPair(7, goncurses.C_BLACK, cursesColor(theme.Selected))
+	goncurses.InitPair(9, goncurses.C_BLACK, cursesColor(theme.Checked))
+
+	// Menu
+	goncurses.InitPair(8, goncurses.C_BLUE, -1)
+
+	goncurses.InitPair(10, goncurses.C_BLACK, goncurses.C_WHITE)
+	return stdscr, nil
+}
+
+// resize fits ncurses to the terminal and redraws everything
+func (s *screen) resize() {
+	if rows, cols, err := output.TermSize(); err == nil && (rows != s.rows || cols != s.cols) {
+		goncurses.ResizeTerm(rows, cols)
+	}
+	s.rows, s.cols = s.stdscr.MaxYX()
+	s.previewLines = nil
+	s.stdscr.Clear()
+	s.dirty = drawAll
+}
+
+// draw redraws the dirty regions and updates the terminal with what changed
+func (s *screen) draw() {
+	if s.dirty == 0 {
+		return
+	}
+	if s.dirty&drawPacks != 0 {
+		// The help box and preview pane are drawn over the list
+		s.dirty |= drawHelp | drawPreview
+		s.offset = s.list.printPacks(s.stdscr, s.view, s.selected)
+	}
+	if s.dirty&drawBar != 0 {
+		printBar(s.stdscr, s.list.Title, s.newSel, s.toSel, s.notSel, s.filter, s.filtering)
+	}
+	if s.dirty&drawHelp != 0 {
+		s.helpRows = printhelp(s.stdscr, s.list.help())
+	}
+	s.stdscr.NoutRefresh()
+
+	if s.dirty&drawPreview != 0 && s.preview && viewPos(s.view, s.selected) != 0 {
+		if s.previewOf != s.selected {
+			s.previewOf = s.selected
+			s.previewScroll = 0
+			s.previewLines = nil
+			s.moved = time.Now()
+		}
+		if s.previewLines == nil {
+			_, mx := s.stdscr.MaxYX()
+			s.previewLines = s.previewText(mx/2 - 4)
+		}
+		s.previewScroll = printPreview(s.stdscr, s.previewLines, s.previewScroll, s.helpRows+1)
+	}
+	goncurses.Update()
+	s.dirty = 0
+}
+
+// move changes the highlighted package
+func (s *screen) move(delta int, wrap bool) {
+	if selected := step(s.view, s.selected, delta, wrap); selected != s.selected {
+		s.selected = selected
+		s.dirty |= drawPacks
+	}
+}
+
+// filterKey handles a key typed into the filter prompt
+func (s *screen) filterKey(ch goncurses.Key) {
+	switch ch {
+	case '\n':
+		s.filtering = false
+	case 27:
+		s.filtering = false
+		s.filter = ""
+	case goncurses.KEY_BACKSPACE, 127, 8:
+		if len(s.filter) > 0 {
+			s.filter = s.filter[:len(s.filter)-1]
+		}
+	default:
+		if ch < 32 || ch >= 127 {
+			return
+		}
+		s.filter += string(rune(ch))
+	}
+	s.setFilter(s.filter)
+}
+
+func (s *screen) setFilter(filter string) {
+	s.filter = filter
+	s.view = filterView(s.list.Packages, filter)
+	if viewPos(s.view, s.selected) == 0 {
+		s.selected = step(s.view, s.selected, 0, false)
+	}
+	s.dirty |= drawPacks | drawBar
+}
+
+// mouse turns a mouse event into an action, toggling clicked packages
+func (s *screen) mouse() string {
+	ms := goncurses.GetMouse()
+	if ms == nil || time.Since(s.lastMouse) < mouseInterval {
+		return ""
+	}
+	s.lastMouse = time.Now()
+	switch ms.State {
+	case goncurses.M_B1_CLICKED:
+		if clicked := getactive(ms.Y, s.rows, s.offset, s.view, packRows(s.cols)); clicked != -1 {
+			s.list.Checked[clicked] = !s.list.Checked[clicked]
+			s.dirty |= drawPacks
+		}
+	case goncurses.M_B4_PRESSED:
+		// Scroll up
+		return "up"
+	case goncurses.M_B5_PRESSED:
+		// Scroll down
+		return "down"
+	}
+	return ""
+}
+
+// handle runs the action bound to a key.
+// It returns the action that closes the list, if it does
+func (s *screen) handle(ch goncurses.Key, action string) (string, bool) {
+	checked := s.list.Checked
+	switch action {
+	case "quit":
+		// Escape clears the filter first
+		if ch == 27 && len(s.filter) > 0 {
+			s.setFilter("")
+			break
+		}
+		return "quit", true
+	case "pageup":
+		s.move((s.rows-3)/packRows(s.cols), false)
+	case "pagedown":
+		s.move(-(s.rows-3)/packRows(s.cols), false)
+	case "bottom":
+		s.move(-len(s.view), false)
+	case "top":
+		s.move(len(s.view), false)
+	case "up":
+		// Scroll forward
+		s.move(1, false)
+	case "down":
+		// Scroll backward
+		s.move(-1, false)
+	case "filter":
+		s.filtering = true
+		s.dirty |= drawBar
+	case "next":
+		// Next match up the list
+		if len(s.filter) > 0 {
+			s.move(1, true)
+		}
+	case "previous":
+		if len(s.filter) > 0 {
+			s.move(-1, true)
+		}
+
+	case "toggle":
+		// Only the packages shown can be selected
+		visible := map[int]bool{}
+		for _, num := range s.view {
+			visible[num] = true
+		}
+
+		switch {
+		case s.notSel:
+			for _, num := range s.view {
+				if num != s.newSel {
+					checked[num] = true
+				}
+			}
+		case s.newSel != 0 && s.toSel != 0:
+			from, to := s.toSel, s.newSel
+			if from > to {
+				from, to = to, from
+			}
+			for i := from; i <= to; i++ {
+				if visible[i] {
+					checked[i] = true
+				}
+			}
+			if visible[to] {
+				s.selected = to
+			}
+		case s.newSel != 0:
+			if visible[s.newSel] {
+				checked[s.newSel] = !checked[s.newSel]
+				s.selected = s.newSel
+			}
+		case visible[s.selected]:
+			checked[s.selected] = !checked[s.selected]
+		}
+		s.newSel, s.toSel, s.notSel = 0, 0, false
+		s.dirty |= drawPacks | drawBar
+
+	case "upstream":
+		if viewPos(s.view, s.selected) != 0 {
+			cm := exec.Command("xdg-open", s.list.Packages[len(s.list.Packages)-s.selected].Upstream)
+			cm.Run()
+		}
+
+	case "details":
+		if viewPos(s.view, s.selected) == 0 {
+			break
+		}
+		// Search results don't have every field
+		s.await()
+		pack := &s.list.Packages[len(s.list.Packages)-s.selected]
+		if s.list.Fill != nil {
+			s.list.Fill(pack)
+			s.filled[s.selected] = true
+		}
+		printDetails(s.stdscr, *pack)
+		// Draw over the popup
+		s.stdscr.Touch()
+		s.dirty = drawAll
+
+	case "preview":
+		s.preview = !s.preview
+		// Hiding the pane uncovers the list
+		s.dirty |= drawPacks
+
+	case "pkgbuild":
+		// The PKGBUILD is only fetched once asked for
+		if s.preview {
+			s.showPkgbuild = !s.showPkgbuild
+			s.previewLines = nil
+			s.moved = time.Now()
+			s.dirty |= drawPreview
+		}
+
+	case "previewup":
+		if s.preview && s.previewScroll > 0 {
+			s.previewScroll--
+			s.dirty |= drawPreview
+		}
+
+	case "previewdown":
+		if s.preview {
+			s.previewScroll++
+			s.dirty |= drawPreview
+		}
+
+	case "install", "remove":
+		if action == "remove" && !s.list.Remove {
+			break
+		}
+		selection := s.list.selection(s.view, s.selected)
+		if len(selection) == 0 && !s.list.AllowNone {
+			break
+		}
+		if action == "remove" && s.list.Cascade != nil && !s.confirmRemove(selection) {
+			break
+		}
+		return action, true
+
+	case "redraw":
+		s.stdscr.Clear()
+		s.dirty = drawAll
+
+	default:
+		if s.list.extra(action) {
+			return action, true
+		}
+		// Package numbers
+		if ch == '-' {
+			if s.newSel != 0 && !s.notSel {
+				s.toSel = s.newSel
+				s.newSel = 0
+				s.dirty |= drawBar
+			}
+		} else if ch == '^' {
+			if !s.notSel {
+				s.notSel = true
+				s.dirty |= drawBar
+			}
+		} else if num, err := strconv.Atoi(string(rune(ch))); err == nil {
+			s.newSel = s.newSel*10 + num
+			s.dirty |= drawBar
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ericm/goncurses"
+	"github.com/ericm/yup/output"
+)
+
+func TestHandle(t *testing.T) {
+	list := &List{
+		Packages: []output.Package{{Name: "d"}, {Name: "c"}, {Name: "b"}, {Name: "a"}},
+		Checked:  map[int]bool{},
+	}
+	s := newScreen(list, nil)
+	s.rows = 20
+	s.dirty = 0
+
+	s.handle('k', "up")
+	if s.selected != 2 || s.dirty != drawPacks {
+		t.Errorf("up: selected %d, dirty %b", s.selected, s.dirty)
+	}
+
+	// Typing numbers only changes the bar
+	s.dirty = 0
+	for _, ch := range "1-3" {
+		s.handle(goncurses.Key(ch), "")
+	}
+	if s.toSel != 1 || s.newSel != 3 || s.dirty != drawBar {
+		t.Errorf("1-3: got %d-%d, dirty %b", s.toSel, s.newSel, s.dirty)
+	}
+	s.handle(' ', "toggle")
+	if !list.Checked[1] || !list.Checked[2] || !list.Checked[3] || list.Checked[4] || s.selected != 3 {
+		t.Errorf("toggle 1-3: checked %v, selected %d", list.Checked, s.selected)
+	}
+
+	// Moving past the end doesn't redraw
+	s.handle('g', "top")
+	s.dirty = 0
+	s.handle('k', "up")
+	if s.selected != 4 || s.dirty != 0 {
+		t.Errorf("up at the top: selected %d, dirty %b", s.selected, s.dirty)
+	}
+
+	if action, done := s.handle('\n', "install"); !done || action != "install" {
+		t.Errorf("install: got %q, %v", action, done)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fills := 0
+	list := &List{
+		Packages: []output.Package{{Name: "b"}, {Name: "a"}},
+		Fill: func(pack *output.Package) {
+			fills++
+			pack.Description = "filled in"
+		},
+	}
+	s := newScreen(list, nil)
+	s.preview = true
+	s.previewOf = 1
+
+	// Nothing is fetched while the highlight is moving
+	s.moved = time.Now()
+	s.load()
+	if s.fetching || !strings.Contains(strings.Join(s.previewText(40), "\n"), "loading…") {
+		t.Fatalf("fetched right after moving: %v", s.previewText(40))
+	}
+
+	s.moved = time.Now().Add(-previewDelay)
+	s.load()
+	if !s.fetching {
+		t.Fatal("not fetching once the highlight stayed put")
+	}
+	s.await()
+	lines := strings.Join(s.previewText(40), "\n")
+	if !strings.Contains(lines, "filled in") || strings.Contains(lines, "loading…") || list.Packages[1].Description != "filled in" {
+		t.Errorf("after loading: %q", lines)
+	}
+
+	s.load()
+	if s.fetching || fills != 1 {
+		t.Errorf("filled %d times", fills)
+	}
+}
@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"github.com/ericm/goncurses"
+	"github.com/ericm/yup/output"
+)
+
+// Colour pairs made for theme colours so far, reset whenever ncurses starts
+var themePairs = map[string]int16{}
+
+// Theme pairs are numbered after the fixed ones
+const firstThemePair = 20
+
+// themePair returns the colour pair drawing a theme colour on the default background
+func themePair(color string) int16 {
+	if pair, ok := themePairs[color]; ok {
+		return pair
+	}
+	pair := int16(firstThemePair + len(themePairs))
+	goncurses.InitPair(pair, cursesColor(color), -1)
+	themePairs[color] = pair
+	return pair
+}
+
+// cursesColor returns the ncurses colour of a theme colour.
+// Bright colours fall back to the normal ones on 8 colour terminals
+func cursesColor(color string) int16 {
+	n, ok := output.ColorNumber(color)
+	if !ok {
+		return -1
+	}
+	if n >= 8 && goncurses.Colors() < 16 {
+		n -= 8
+	}
+	return int16(n)
+}
@@ -2,6 +2,7 @@ package update
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,9 +11,12 @@ import (
 	"strings"
 
 	"github.com/ericm/yup/config"
+	"github.com/ericm/yup/index"
+	"github.com/ericm/yup/news"
 	"github.com/ericm/yup/output"
+	"github.com/ericm/yup/rebuild"
 	"github.com/ericm/yup/sync"
-	"github.com/mikkeloscar/aur"
+	"github.com/ericm/yup/tui"
 )
 
 // Installed Packages representation
@@ -28,56 +32,63 @@ func Update() error {
 	if strings.Contains(os.Args[len(os.Args)-1], "yy") {
 		flg = "-Syyu"
 	}
+	// Manual interventions are announced in the news
+	if ok, err := news.Check(); err != nil {
+		return err
+	} else if !ok {
+		output.Printf("System upgrade cancelled")
+		return nil
+	}
+
 	output.Printf("Updating from local repositories")
-	cmd := exec.Command("sudo", "pacman", flg)
+	refresh := exec.Command("sudo", "pacman", strings.TrimSuffix(flg, "u"))
+	output.SetStd(refresh)
+	if err := refresh.Run(); err != nil {
+		return err
+	}
+
+	// Keyrings go first, or new packages may be signed by keys not yet trusted
+	pending, err := pendingUpgrades()
+	if err != nil {
+		return err
+	}
+	if keyrings := pendingKeyrings(pending, config.GetConfig().UserFile.ExtraKeyrings); len(keyrings) > 0 {
+		output.Printf("Upgrading %s first so packages signed with new keys can be verified", strings.Join(keyrings, ", "))
+		keys := exec.Command("sudo", append([]string{"pacman", "-S", "--needed"}, keyrings...)...)
+		output.SetStd(keys)
+		if err := keys.Run(); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.Command("sudo", "pacman", "-Su")
 	output.SetStd(cmd)
 	if err := cmd.Run(); err != nil {
 		return err
 	}
 
 	// Launch AUR update
-	return AurUpdate()
+	if err := AurUpdate(); err != nil {
+		return err
+	}
+
+	// Library bumps from the repos can break AUR packages.
+	// Which to rebuild is asked, so silent upgrades leave it out
+	if config.GetConfig().UserFile.SilentUpdate {
+		return nil
+	}
+	return rebuild.CheckUpgraded(pending)
 }
 
 // AurUpdate checks for update in the AUR
 func AurUpdate() error {
 	output.Printf("Checking for AUR updates...")
 
-	// Filter installed packages
-
-	// Get output of pacman -Q
-	cmd := exec.Command("pacman", "-Qm")
-	inp, err := cmd.Output()
+	updates, outdated, err := pendingAur()
 	if err != nil {
 		return err
 	}
 
-	var updates []installedPack
-	var outdated []installedPack
-
-	packStr := strings.Split(string(inp), "\n")
-	for _, pack := range packStr {
-		p := strings.Split(pack, " ")
-		if len(p) < 2 {
-			continue
-		}
-		pack := installedPack{name: p[0], version: p[1]}
-		aurPack, errAur := aur.Info([]string{pack.name})
-		if errAur != nil {
-			output.PrintErr("%s", errAur)
-		}
-		if len(aurPack) > 0 {
-			if newerVersion(pack.version, aurPack[0].Version) {
-				pack.newVersion = aurPack[0].Version
-				updates = append(updates, pack)
-			} else if pack.version != aurPack[0].Version {
-				// Package must be newer than AUR
-				pack.newVersion = aurPack[0].Version
-				outdated = append(outdated, pack)
-			}
-		}
-	}
-
 	scanner := bufio.NewReader(os.Stdin)
 	if len(outdated) > 0 {
 		fmt.Print("\n")
@@ -92,6 +103,13 @@ func AurUpdate() error {
 		return nil
 	}
 	output.Printf("Found %d AUR package(s) to update:", len(updates))
+	if tui.Enabled() {
+		syncUp, err := pickUpdates(updates)
+		if err != nil {
+			return err
+		}
+		return syncUpdates(syncUp)
+	}
 	for i, pack := range updates {
 		fmt.Printf("    %-3d \033[1m%s\033[0m \033[91m%s\033[0m -> \033[92m%s\033[0m\n", i+1, pack.name, pack.version, pack.newVersion)
 	}
@@ -150,10 +168,184 @@ func AurUpdate() error {
 		}
 	}
 
+	return syncUpdates(syncUp)
+}
+
+// pickUpdates shows the updates in a checklist, all checked, and returns the checked ones
+func pickUpdates(updates []installedPack) ([]string, error) {
+	packs := []output.Package{}
+	info := []string{}
+	defaults := []bool{}
+	for _, pack := range updates {
+		packs = append(packs, output.Package{Name: pack.name, Version: pack.version, Repo: "aur", Aur: true})
+		info = append(info, "-> "+pack.newVersion)
+		defaults = append(defaults, true)
+	}
+
+	checked, ok := tui.Checklist("Uncheck updates not to install", "Update", packs, info, defaults)
+	if !ok {
+		return nil, errors.New("Update cancelled")
+	}
+	names := []string{}
+	for i, pack := range updates {
+		if checked[i] {
+			names = append(names, pack.name)
+		}
+	}
+	return names, nil
+}
+
+func syncUpdates(names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
 	if config.GetConfig().UserFile.SilentUpdate {
-		return sync.Sync(syncUp, true, true)
+		return sync.Sync(names, true, true)
+	}
+	return sync.Sync(names, true, false)
+}
+
+// Plan prints the pending updates as JSON instead of installing them.
+// The sync databases aren't refreshed, as that needs root
+func Plan(aurOnly bool) error {
+	plan := output.JSONPlan{Repo: []output.JSONUpdate{}}
+	if !aurOnly {
+		out, err := pacmanQu()
+		if err != nil {
+			return err
+		}
+		plan.Repo = jsonUpdates(parseVersions(out))
+	}
+
+	updates, outdated, err := pendingAur()
+	if err != nil {
+		return err
+	}
+	plan.Aur, plan.Newer = jsonUpdates(updates), jsonUpdates(outdated)
+	return output.PrintJSON(plan)
+}
+
+func jsonUpdates(packs []installedPack) []output.JSONUpdate {
+	out := []output.JSONUpdate{}
+	for _, pack := range packs {
+		out = append(out, output.JSONUpdate{Name: pack.name, Version: pack.version, NewVersion: pack.newVersion})
+	}
+	return out
+}
+
+// Prebuild builds pending AUR updates without installing them
+func Prebuild() error {
+	output.Printf("Checking for AUR updates to prebuild...")
+
+	updates, _, err := pendingAur()
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for _, pack := range updates {
+		names = append(names, pack.name)
+	}
+	return sync.Prebuild(names)
+}
+
+// pendingAur compares foreign packages to the AUR.
+// It returns packages with updates and packages newer than their AUR version
+func pendingAur() ([]installedPack, []installedPack, error) {
+	// Get output of pacman -Q
+	cmd := exec.Command("pacman", "-Qm")
+	inp, err := cmd.Output()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var updates []installedPack
+	var outdated []installedPack
+
+	packStr := strings.Split(string(inp), "\n")
+	for _, pack := range packStr {
+		p := strings.Split(pack, " ")
+		if len(p) < 2 {
+			continue
+		}
+		pack := installedPack{name: p[0], version: p[1]}
+		aurPack, errAur := index.Info([]string{pack.name})
+		if errAur != nil {
+			output.PrintErr("%s", errAur)
+		}
+		if len(aurPack) > 0 {
+			if newerVersion(pack.version, aurPack[0].Version) {
+				pack.newVersion = aurPack[0].Version
+				updates = append(updates, pack)
+			} else if pack.version != aurPack[0].Version {
+				// Package must be newer than AUR
+				pack.newVersion = aurPack[0].Version
+				outdated = append(outdated, pack)
+			}
+		}
+	}
+	return updates, outdated, nil
+}
+
+// pendingUpgrades returns the repo packages with a newer version in the sync databases
+func pendingUpgrades() ([]string, error) {
+	out, err := pacmanQu()
+	if err != nil {
+		return nil, err
+	}
+	return parseUpgrades(out), nil
+}
+
+func pacmanQu() (string, error) {
+	out, err := exec.Command("pacman", "-Qu").Output()
+	if err != nil {
+		// pacman exits with 1 when there is nothing to upgrade
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// parseUpgrades parses pacman -Qu output, skipping ignored packages
+func parseUpgrades(out string) []string {
+	names := []string{}
+	for _, pack := range parseVersions(out) {
+		names = append(names, pack.name)
+	}
+	return names
+}
+
+// parseVersions parses the "name old -> new" lines of pacman -Qu
+func parseVersions(out string) []installedPack {
+	packs := []installedPack{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.Contains(line, "[ignored]") {
+			continue
+		}
+		pack := installedPack{name: fields[0]}
+		if len(fields) >= 4 {
+			pack.version, pack.newVersion = fields[1], fields[3]
+		}
+		packs = append(packs, pack)
+	}
+	return packs
+}
+
+// pendingKeyrings filters pending for archlinux-keyring and the configured extra keyrings
+func pendingKeyrings(pending []string, extra []string) []string {
+	keyrings := map[string]bool{"archlinux-keyring": true}
+	for _, name := range extra {
+		keyrings[name] = true
+	}
+	out := []string{}
+	for _, name := range pending {
+		if keyrings[name] {
+			out = append(out, name)
+		}
 	}
-	return sync.Sync(syncUp, true, false)
+	return out
 }
 
 func newerVersion(oldVersion, newVersion string) bool {
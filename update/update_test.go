@@ -1 +1,25 @@
 package update
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPendingKeyrings(t *testing.T) {
+	out := `archlinux-keyring 20200422-1 -> 20200603-1
+linux 5.6.15.arch1-1 -> 5.7.arch1-1
+chaotic-keyring 20200101-1 -> 20200601-1
+firefox 76.0.1-1 -> 77.0-1 [ignored]
+`
+	pending := parseUpgrades(out)
+	if want := []string{"archlinux-keyring", "linux", "chaotic-keyring"}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("got %v, want %v", pending, want)
+	}
+
+	if got := pendingKeyrings(pending, nil); !reflect.DeepEqual(got, []string{"archlinux-keyring"}) {
+		t.Errorf("got %v", got)
+	}
+	if got := pendingKeyrings(pending, []string{"chaotic-keyring"}); !reflect.DeepEqual(got, []string{"archlinux-keyring", "chaotic-keyring"}) {
+		t.Errorf("got %v", got)
+	}
+}